@@ -0,0 +1,360 @@
+package http
+
+import (
+	"fmt"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/exceptions"
+	"github.com/goal-web/supports/utils"
+	"github.com/goal-web/validation"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+var (
+	httpRequestType = reflect.TypeOf((*contracts.HttpRequest)(nil)).Elem()
+
+	// bindingTags 带有这些 tag 的结构体参数会从请求中绑定
+	bindingTags = []string{"json", "form", "query", "param", "xml", "validate"}
+)
+
+// argumentBinder 从请求中解析处理器的某个参数
+type argumentBinder func(request contracts.HttpRequest, models map[string]reflect.Value) reflect.Value
+
+// boundModel 路由中绑定了模型的参数
+type boundModel struct {
+	param   routeParam
+	binding *modelBinding
+}
+
+// boundHandler 支持从路由参数和请求体注入参数的处理器
+// 绑定了模型的参数按路由中的顺序注入解析出的模型，其余标量参数按顺序绑定路径参数，路径参数用完后按顺序绑定 Inputs 声明的请求字段，
+// 都没有对应的字段时在挂载时 panic，
+// 带有绑定 tag 且没有在容器中绑定的结构体从请求中绑定并校验，剩下的参数交给容器注入
+type boundHandler struct {
+	app       contracts.Application
+	handler   contracts.MagicalFunc
+	arguments []reflect.Type
+	binders   []argumentBinder
+	models    []boundModel
+}
+
+// compileHandler 根据路由路径和模型绑定编译路由处理器，没有需要绑定的参数时直接返回原处理器
+func (this *Router) compileHandler(routeInstance contracts.Route) contracts.MagicalFunc {
+	var (
+		handler = routeInstance.Handler()
+		bound   = &boundHandler{
+			app:       this.app,
+			handler:   handler,
+			arguments: []reflect.Type{httpRequestType},
+			binders:   make([]argumentBinder, handler.NumIn()),
+		}
+		models    = make([]boundModel, 0)
+		params    = make([]routeParam, 0)
+		inputs    []string
+		used      = make(map[int]bool)
+		hasBinder = false
+		useModel  = false
+	)
+
+	if attrs := attributesOf(routeInstance); attrs != nil {
+		inputs = attrs.inputs
+	}
+	for _, param := range parseRouteParams(routeInstance.Path()) {
+		if binding, exists := this.bindings[param.name]; exists {
+			models = append(models, boundModel{param: param, binding: binding})
+		} else {
+			params = append(params, param)
+		}
+	}
+
+	for index, argType := range handler.Arguments() {
+		if model := findModel(models, argType, used); model != nil {
+			bound.binders[index] = modelBinder(model.param.name)
+			hasBinder, useModel = true, true
+			continue
+		}
+		switch {
+		case this.isController(argType):
+			bound.arguments = append(bound.arguments, argType)
+		case isScalar(argType):
+			switch {
+			case len(params) > 0:
+				bound.binders[index] = paramBinder(params[0].raw, argType)
+				params = params[1:]
+			case len(inputs) > 0:
+				bound.binders[index] = inputBinder(inputs[0], argType)
+				inputs = inputs[1:]
+			default:
+				panic(exceptions.New("handler has more scalar arguments than route params and inputs", contracts.Fields{
+					"path":     routeInstance.Path(),
+					"argument": index,
+					"type":     argType.String(),
+				}))
+			}
+			hasBinder = true
+		case this.isBindable(argType):
+			bound.binders[index] = structBinder(argType)
+			hasBinder = true
+		default:
+			bound.arguments = append(bound.arguments, argType)
+		}
+	}
+
+	if !hasBinder {
+		return handler
+	}
+	if useModel {
+		bound.models = models
+	}
+
+	return bound
+}
+
+func (handler *boundHandler) NumOut() int {
+	return handler.handler.NumOut()
+}
+
+func (handler *boundHandler) NumIn() int {
+	return len(handler.arguments)
+}
+
+func (handler *boundHandler) Arguments() []reflect.Type {
+	return handler.arguments
+}
+
+func (handler *boundHandler) Returns() []reflect.Type {
+	return handler.handler.Returns()
+}
+
+// Call 第一个参数是请求，其余是容器注入的参数
+func (handler *boundHandler) Call(in []reflect.Value) []reflect.Value {
+	var (
+		request   = in[0].Interface().(contracts.HttpRequest)
+		injected  = in[1:]
+		arguments = make([]reflect.Value, len(handler.binders))
+		models    map[string]reflect.Value
+	)
+
+	if len(handler.models) > 0 {
+		models = handler.resolveModels(request)
+	}
+
+	for index, binder := range handler.binders {
+		if binder != nil {
+			arguments[index] = binder(request, models)
+		} else {
+			arguments[index] = injected[0]
+			injected = injected[1:]
+		}
+	}
+
+	return handler.handler.Call(arguments)
+}
+
+// resolveModels 按路由中的顺序解析模型，后面的模型以前一个模型作为作用域
+func (handler *boundHandler) resolveModels(request contracts.HttpRequest) map[string]reflect.Value {
+	var (
+		models = make(map[string]reflect.Value, len(handler.models))
+		parent interface{}
+	)
+
+	for _, model := range handler.models {
+		var (
+			query = ModelQuery{
+				Param:  model.param.name,
+				Field:  model.param.field,
+				Value:  request.Param(model.param.raw),
+				Parent: parent,
+			}
+			results = handler.app.StaticCall(model.binding.resolver, query, request)
+		)
+
+		if len(results) > 1 {
+			if err, isErr := results[1].(error); isErr && err != nil {
+				panic(Exception{
+					Exception: exceptions.WithError(err, contracts.Fields{"param": query.Param, "value": query.Value}),
+					Request:   request,
+				})
+			}
+		}
+
+		var value = reflect.ValueOf(results[0])
+		if results[0] == nil || (value.Kind() == reflect.Ptr && value.IsNil()) {
+			panic(Exception{
+				Exception: exceptions.New("model not found", contracts.Fields{
+					"status": http.StatusNotFound,
+					"param":  query.Param,
+					"value":  query.Value,
+				}),
+				Request: request,
+			})
+		}
+
+		models[model.param.name] = value
+		parent = results[0]
+	}
+
+	return models
+}
+
+// findModel 按路由中的顺序查找还没有注入的同类型模型，例如 /users/:user/friends/:friend 的两个 *User 参数依次注入 user 和 friend
+func findModel(models []boundModel, argType reflect.Type, used map[int]bool) *boundModel {
+	for index := range models {
+		if !used[index] && models[index].binding.modelType == argType {
+			used[index] = true
+			return &models[index]
+		}
+	}
+	return nil
+}
+
+func isScalar(argType reflect.Type) bool {
+	switch argType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isBindable 判断结构体参数是否从请求中绑定，容器中绑定了的或者带有 di tag 的结构体仍然由容器注入
+func (this *Router) isBindable(argType reflect.Type) bool {
+	if argType.Kind() == reflect.Ptr {
+		argType = argType.Elem()
+	}
+	if argType.Kind() != reflect.Struct || this.app.HasBound(utils.GetTypeKey(argType)) {
+		return false
+	}
+	for i := 0; i < argType.NumField(); i++ {
+		if _, exists := argType.Field(i).Tag.Lookup("di"); exists {
+			return false
+		}
+	}
+	for i := 0; i < argType.NumField(); i++ {
+		for _, tag := range bindingTags {
+			if _, exists := argType.Field(i).Tag.Lookup(tag); exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// modelBinder 注入解析出的模型
+func modelBinder(param string) argumentBinder {
+	return func(_ contracts.HttpRequest, models map[string]reflect.Value) reflect.Value {
+		return models[param]
+	}
+}
+
+// paramBinder 绑定路径参数
+func paramBinder(name string, argType reflect.Type) argumentBinder {
+	return func(request contracts.HttpRequest, _ map[string]reflect.Value) reflect.Value {
+		return convertArgument(request, "param", name, request.Param(name), argType)
+	}
+}
+
+// inputBinder 绑定 query、表单或者 json 请求体中的字段，字段不存在时注入零值
+func inputBinder(name string, argType reflect.Type) argumentBinder {
+	return func(request contracts.HttpRequest, _ map[string]reflect.Value) reflect.Value {
+		var input = inputValue(request, name)
+		if input == "" {
+			return reflect.New(argType).Elem()
+		}
+		return convertArgument(request, "input", name, input, argType)
+	}
+}
+
+// inputValue 依次从 query、表单和 json 请求体中读取字段
+func inputValue(request contracts.HttpRequest, name string) string {
+	if value := request.QueryParam(name); value != "" {
+		return value
+	}
+	if value := request.FormValue(name); value != "" {
+		return value
+	}
+	if value, exists := request.Fields()[name]; exists && value != nil {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// convertArgument 把字符串转换成参数类型，转换失败响应 400
+func convertArgument(request contracts.HttpRequest, kind, name, raw string, argType reflect.Type) reflect.Value {
+	var value = reflect.New(argType).Elem()
+	if err := convertParam(raw, value); err != nil {
+		panic(Exception{
+			Exception: exceptions.WithPrevious(err, contracts.Fields{
+				"status": http.StatusBadRequest,
+				kind:     name,
+				"value":  raw,
+			}, nil),
+			Request: request,
+		})
+	}
+	return value
+}
+
+// structBinder 从请求中绑定结构体并校验，绑定失败响应 400，校验失败响应 422
+func structBinder(argType reflect.Type) argumentBinder {
+	var isPtr = argType.Kind() == reflect.Ptr
+	if isPtr {
+		argType = argType.Elem()
+	}
+
+	return func(request contracts.HttpRequest, _ map[string]reflect.Value) reflect.Value {
+		var value = reflect.New(argType)
+		if err := request.Bind(value.Interface()); err != nil {
+			panic(Exception{
+				Exception: exceptions.WithPrevious(err, contracts.Fields{"status": http.StatusBadRequest}, nil),
+				Request:   request,
+			})
+		}
+		if err := validation.Struct(value.Interface()); err != nil {
+			panic(Exception{
+				Exception: exceptions.WithPrevious(err, contracts.Fields{"status": http.StatusUnprocessableEntity}, nil),
+				Request:   request,
+			})
+		}
+		if isPtr {
+			return value
+		}
+		return value.Elem()
+	}
+}
+
+// convertParam 把字符串参数转换成目标类型
+func convertParam(param string, value reflect.Value) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(param)
+	case reflect.Bool:
+		var result, err = strconv.ParseBool(param)
+		if err != nil {
+			return err
+		}
+		value.SetBool(result)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var result, err = strconv.ParseInt(param, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(result)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var result, err = strconv.ParseUint(param, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(result)
+	case reflect.Float32, reflect.Float64:
+		var result, err = strconv.ParseFloat(param, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(result)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScalarArgumentsFromPathAndInputs(t *testing.T) {
+	var router = newTestRouter()
+	router.Get("/users/:id/posts", func(id, page int, query string) string {
+		return fmt.Sprintf("%d %d %s", id, page, query)
+	}, Inputs("page", "q"))
+
+	for path, expected := range map[string]string{
+		"/users/3/posts?page=2&q=go": "3 2 go",
+		"/users/3/posts":             "3 0 ", // 缺少的字段注入零值
+	} {
+		if body := serve(router, http.MethodGet, path).Body.String(); body != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, body)
+		}
+	}
+	if response := serve(router, http.MethodGet, "/users/3/posts?page=two"); response.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid input, got %d", response.Code)
+	}
+}
+
+func TestScalarArgumentsFromBody(t *testing.T) {
+	var router = newTestRouter()
+	router.Post("/search", func(page int, active bool) string {
+		return fmt.Sprintf("%d %t", page, active)
+	}, Inputs("page", "active"))
+
+	for contentType, body := range map[string]string{
+		"application/x-www-form-urlencoded": "page=5&active=true",
+		"application/json":                  `{"page": 5, "active": true}`,
+	} {
+		var request = httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(body))
+		request.Header.Set("Content-Type", contentType)
+		if response := serveRecorder(router, request); response.Body.String() != "5 true" {
+			t.Errorf("%s: unexpected response %q", contentType, response.Body.String())
+		}
+	}
+}
+
+func TestUnboundScalarArgumentPanicsAtMount(t *testing.T) {
+	var router = newTestRouter()
+	router.Get("/page", func(page int) string { return "" })
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a scalar argument without a route param or input to panic")
+		}
+	}()
+	router.prepare()
+}
+
+type testUser struct {
+	name string
+}
+
+func TestSameTypedModelsBindByPosition(t *testing.T) {
+	var (
+		router   = newTestRouter()
+		resolver = func(query ModelQuery) *testUser {
+			if query.Value == "missing" {
+				return nil
+			}
+			return &testUser{name: query.Value}
+		}
+	)
+	router.Model("user", resolver)
+	router.Model("friend", resolver)
+	router.Get("/users/:user/friends/:friend", func(user, friend *testUser) string {
+		return user.name + " " + friend.name
+	})
+
+	if body := serve(router, http.MethodGet, "/users/alice/friends/bob").Body.String(); body != "alice bob" {
+		t.Fatalf("expected each model to be injected once in route order, got %q", body)
+	}
+	if response := serve(router, http.MethodGet, "/users/alice/friends/missing"); response.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing model, got %d", response.Code)
+	}
+}
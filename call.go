@@ -0,0 +1,96 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"reflect"
+)
+
+// argumentSource 装配时确定的参数来源
+type argumentSource int
+
+const (
+	requestArgument     argumentSource = iota // 当前请求
+	httpRequestArgument                       // contracts.HttpRequest 类型的当前请求，预先转换避免反射调用时逐个比对接口方法
+	nextArgument                              // 下一个中间件
+	paramsArgument                            // 中间件参数
+)
+
+var (
+	requestType = reflect.TypeOf(&Request{})
+	pipeType    = reflect.TypeOf(contracts.Pipe(nil))
+	paramsType  = reflect.TypeOf(MiddlewareParams(nil))
+)
+
+// compiledCall 装配时分析好参数来源的中间件或者处理器
+// 参数都来自请求、下一个中间件和中间件参数时直接调用，不经过容器解析，否则交给容器注入
+type compiledCall struct {
+	function   contracts.MagicalFunc
+	sources    []argumentSource
+	direct     bool
+	middleware bool
+	params     MiddlewareParams
+	paramsArg  reflect.Value
+}
+
+func compileCall(function contracts.MagicalFunc, middleware bool) *compiledCall {
+	var call = &compiledCall{function: function, direct: true, middleware: middleware}
+	if named, isNamed := function.(*namedMiddleware); isNamed {
+		call.function, call.params = named.MagicalFunc, named.params
+	}
+	call.paramsArg = reflect.ValueOf(call.params)
+
+	for _, argType := range call.function.Arguments() {
+		switch {
+		case argType == httpRequestType:
+			call.sources = append(call.sources, httpRequestArgument)
+		case requestType.AssignableTo(argType):
+			call.sources = append(call.sources, requestArgument)
+		case middleware && pipeType.AssignableTo(argType):
+			call.sources = append(call.sources, nextArgument)
+		case middleware && paramsType.AssignableTo(argType):
+			call.sources = append(call.sources, paramsArgument)
+		default:
+			call.direct = false
+		}
+	}
+	return call
+}
+
+// call 调用并返回第一个返回值，next 只在调用中间件时使用
+func (this *Router) call(call *compiledCall, passable interface{}, next contracts.Pipe) interface{} {
+	var request, isRequest = passable.(*Request)
+	if !call.direct || !isRequest {
+		var results []interface{}
+		switch {
+		case !call.middleware:
+			results = this.app.StaticCall(call.function, passable)
+		case call.params != nil:
+			results = this.app.StaticCall(call.function, passable, next, call.params)
+		default:
+			results = this.app.StaticCall(call.function, passable, next)
+		}
+		if len(results) > 0 {
+			return results[0]
+		}
+		return nil
+	}
+
+	var arguments = make([]reflect.Value, len(call.sources))
+	for index, source := range call.sources {
+		switch source {
+		case requestArgument:
+			arguments[index] = reflect.ValueOf(request)
+		case httpRequestArgument:
+			var httpRequest contracts.HttpRequest = request
+			arguments[index] = reflect.ValueOf(&httpRequest).Elem()
+		case nextArgument:
+			arguments[index] = reflect.ValueOf(next)
+		case paramsArgument:
+			arguments[index] = call.paramsArg
+		}
+	}
+	if results := call.function.Call(arguments); len(results) > 0 {
+		return results[0].Interface()
+	}
+	return nil
+}
@@ -1,7 +1,42 @@
 package http
 
+import (
+	"os"
+	"time"
+)
+
 type Config struct {
 	Address string
 	Host    string
 	Port    string
+
+	// Engine 路由引擎，可选 echo（默认）、http 或者通过 RegisterEngine 注册的引擎，请求上下文在所有引擎中都是 echo.Context
+	Engine string
+
+	// H2C 在明文连接上支持 HTTP/2，适合内部服务之间的通信
+	H2C bool
+
+	// Http3 HTTP/3 服务，为 nil 时不开启
+	Http3 Http3Server
+
+	// Http3Address HTTP/3 监听的 UDP 地址，为空时与 http 服务的地址相同
+	Http3Address string
+
+	// Listeners 额外的监听器，键为监听器名称，路由可以通过 Listener 选项绑定到指定的监听器
+	Listeners map[string]ListenerConfig
+
+	// HotRestart 收到 RestartSignal 时热重启，新进程继承所有监听器，旧进程处理完请求后退出
+	HotRestart bool
+
+	// RestartSignal 触发热重启的信号，默认为 SIGHUP
+	RestartSignal os.Signal
+
+	// ShutdownTimeout 热重启时等待旧进程处理完请求的时间，默认为 30 秒
+	ShutdownTimeout time.Duration
+
+	// ReadyTimeout 热重启时等待新进程开始服务的时间，超时后旧进程继续服务，默认为 30 秒
+	ReadyTimeout time.Duration
+
+	// TrustedProxies 可信代理，可以是 ip、cidr 或者 loopback、private、linklocal、*，为空时只信任回环地址，内网中的代理需要配置，例如 private
+	TrustedProxies []string
 }
@@ -0,0 +1,44 @@
+package http
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Where 约束路由参数必须完整匹配正则表达式，不匹配的请求会尝试下一个路由，都不匹配时响应 404
+// 可以传给路由，也可以传给路由组，例如 router.Get("/users/:id", handler, http.Where("id", "[0-9]+"))
+func Where(param, pattern string) RouteOption {
+	var compiled = regexp.MustCompile("^(?:" + pattern + ")$")
+	return func(attributes *attributes) {
+		attributes.constraints[param] = compiled
+	}
+}
+
+// WhereNumber 约束路由参数为数字
+func WhereNumber(param string) RouteOption {
+	return Where(param, "[0-9]+")
+}
+
+// WhereAlpha 约束路由参数为字母
+func WhereAlpha(param string) RouteOption {
+	return Where(param, "[a-zA-Z]+")
+}
+
+// WhereAlphaNumeric 约束路由参数为字母或数字
+func WhereAlphaNumeric(param string) RouteOption {
+	return Where(param, "[a-zA-Z0-9]+")
+}
+
+// WhereUUID 约束路由参数为 UUID
+func WhereUUID(param string) RouteOption {
+	return Where(param, "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}")
+}
+
+// WhereIn 约束路由参数为给定值之一
+func WhereIn(param string, values ...string) RouteOption {
+	var quoted = make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, regexp.QuoteMeta(value))
+	}
+	return Where(param, strings.Join(quoted, "|"))
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"net/http"
+	"testing"
+)
+
+func assertResponses(t *testing.T, router *Router, expected map[string]string) {
+	t.Helper()
+	for path, body := range expected {
+		var response = serve(router, http.MethodGet, path)
+		if body == "" {
+			if response.Code != http.StatusNotFound {
+				t.Errorf("%s: expected 404, got %d %q", path, response.Code, response.Body.String())
+			}
+		} else if response.Body.String() != body {
+			t.Errorf("%s: expected %q, got %d %q", path, body, response.Code, response.Body.String())
+		}
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	var router = newTestRouter()
+	router.Get("/users/:id", func(request contracts.HttpRequest) string {
+		return "id " + request.Param("id")
+	}, WhereNumber("id"))
+	router.Get("/users/:name", func(request contracts.HttpRequest) string {
+		return "name " + request.Param("name")
+	}, WhereIn("name", "alice", "bob"))
+	var orders = router.Group("/orders", WhereUUID("order"))
+	orders.Get("/:order", func(request contracts.HttpRequest) string {
+		return "order " + request.Param("order")
+	})
+	orders.Get("/export/all", func() string { return "export" }) // 组的约束不影响没有该参数的路由
+
+	assertResponses(t, router, map[string]string{
+		"/users/12":  "id 12",
+		"/users/bob": "name bob",
+		"/users/eve": "",
+		"/orders/6f1c2a9e-3b7d-4c8e-9a0b-1d2e3f4a5b6c": "order 6f1c2a9e-3b7d-4c8e-9a0b-1d2e3f4a5b6c",
+		"/orders/42":         "",
+		"/orders/export/all": "export",
+	})
+}
+
+func TestConstrainedWildcardRoutes(t *testing.T) {
+	var router = newTestRouter()
+	router.Get("/files/*", func(request contracts.HttpRequest) string {
+		return "numeric " + request.Param("*")
+	}, WhereNumber("*"))
+	router.Get("/files/*", func(request contracts.HttpRequest) string {
+		return "text " + request.Param("*")
+	}, Where("*", `[a-z/]+\.txt`))
+
+	assertResponses(t, router, map[string]string{
+		"/files/123":        "numeric 123",
+		"/files/docs/a.txt": "text docs/a.txt",
+		"/files/a.png":      "",
+	})
+}
+
+func TestConstraintOnCustomKey(t *testing.T) {
+	var router = newTestRouter()
+	router.Get("/posts/:id", func(request contracts.HttpRequest) string {
+		return "id " + request.Param("id")
+	}, WhereNumber("id"))
+	router.Get("/posts/:post:slug", func(request contracts.HttpRequest) string {
+		return "slug " + request.Param("post:slug")
+	}, Where("post", "[a-z-]+"))
+
+	assertResponses(t, router, map[string]string{
+		"/posts/7":           "id 7",
+		"/posts/hello-world": "slug hello-world",
+		"/posts/Hello":       "",
+	})
+}
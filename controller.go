@@ -0,0 +1,78 @@
+package http
+
+import (
+	"github.com/goal-web/container"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/exceptions"
+	"reflect"
+	"strings"
+)
+
+// ControllerMiddlewares 控制器可以实现该接口声明自己的中间件，这些中间件作用于以 "Controller@Method" 注册的所有路由
+type ControllerMiddlewares interface {
+	Middlewares() []interface{}
+}
+
+// Controller 注册控制器，之后可以用 "UserController@Index" 作为路由处理器
+// controller 可以是控制器实例，每个请求都会创建新的实例并通过容器注入带有 di tag 的字段；
+// 也可以是构造函数，例如 func(users *UserService) *UserController，参数由容器注入，每个请求调用一次
+func (this *Router) Controller(name string, controller interface{}) {
+	var controllerType = reflect.TypeOf(controller)
+
+	if controllerType.Kind() == reflect.Func {
+		this.app.Bind(name, controller)
+		controllerType = container.NewMagicalFunc(controller).Returns()[0]
+	} else if controllerType.Kind() != reflect.Ptr {
+		controllerType = reflect.PtrTo(controllerType)
+	}
+
+	this.controllers[name] = controllerType
+}
+
+// resolveAction 解析 "Controller@Method" 形式的处理器，控制器作为方法的第一个参数由容器按请求解析
+func (this *Router) resolveAction(action string) (contracts.MagicalFunc, []contracts.MagicalFunc) {
+	var parts = strings.Split(action, "@")
+	if len(parts) != 2 {
+		panic(exceptions.New("controller action must be in the form Controller@Method", contracts.Fields{
+			"action": action,
+		}))
+	}
+
+	var controllerType, exists = this.controllers[parts[0]]
+	if !exists {
+		panic(exceptions.New("controller is not registered", contracts.Fields{
+			"action": action,
+		}))
+	}
+
+	var method, existsMethod = controllerType.MethodByName(parts[1])
+	if !existsMethod {
+		panic(exceptions.New("controller method does not exist", contracts.Fields{
+			"action": action,
+		}))
+	}
+
+	var middlewares []contracts.MagicalFunc
+	if declared, ok := zeroController(controllerType).(ControllerMiddlewares); ok {
+		middlewares = convertToMiddlewares(declared.Middlewares()...)
+	}
+
+	return container.NewMagicalFunc(method.Func.Interface()), middlewares
+}
+
+// isController 判断参数是否为已注册的控制器，控制器总是由容器解析
+func (this *Router) isController(argType reflect.Type) bool {
+	for _, controllerType := range this.controllers {
+		if controllerType == argType {
+			return true
+		}
+	}
+	return false
+}
+
+func zeroController(controllerType reflect.Type) interface{} {
+	if controllerType.Kind() == reflect.Ptr {
+		return reflect.New(controllerType.Elem()).Interface()
+	}
+	return reflect.New(controllerType).Elem().Interface()
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"fmt"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/utils"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type testUserService struct {
+	prefix string
+}
+
+type testUserController struct {
+	users    *testUserService
+	instance int
+}
+
+func (controller *testUserController) Show(id int) string {
+	return fmt.Sprintf("%s %d #%d", controller.users.prefix, id, controller.instance)
+}
+
+func (controller *testUserController) Middlewares() []interface{} {
+	return []interface{}{func(request *Request, next contracts.Pipe) interface{} {
+		request.Response().Header().Set("X-Controller", "users")
+		return next(request)
+	}}
+}
+
+type testPostController struct {
+	Users *testUserService `di:""`
+}
+
+func (controller testPostController) Index() string {
+	return "posts by " + controller.Users.prefix
+}
+
+func TestControllerActions(t *testing.T) {
+	var (
+		router    = newTestRouter()
+		instances = 0
+	)
+	router.app.Instance(utils.GetTypeKey(reflect.TypeOf(&testUserService{})), &testUserService{prefix: "user"})
+	router.Controller("UserController", func(users *testUserService) *testUserController {
+		instances++
+		return &testUserController{users: users, instance: instances}
+	})
+	router.Controller("PostController", testPostController{})
+	router.Get("/users/:id", "UserController@Show")
+	router.Get("/posts", "PostController@Index")
+
+	for index, expected := range []string{"user 3 #1", "user 3 #2"} {
+		var response = serve(router, http.MethodGet, "/users/3")
+		if response.Body.String() != expected || response.Header().Get("X-Controller") != "users" {
+			t.Fatalf("request %d: unexpected response %q with middleware header %q", index, response.Body.String(), response.Header().Get("X-Controller"))
+		}
+	}
+	if body := serve(router, http.MethodGet, "/posts").Body.String(); body != "posts by user" {
+		t.Fatalf("expected di fields to be injected, got %q", body)
+	}
+}
+
+func TestUnknownControllerAction(t *testing.T) {
+	for _, action := range []string{"MissingController@Index", "UserController@Missing", "UserController"} {
+		var router = newTestRouter()
+		router.Controller("UserController", &testUserController{})
+		router.Get("/", action)
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected mounting to panic", action)
+				}
+			}()
+			router.prepare()
+		}()
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"fmt"
+	"github.com/goal-web/contracts"
+	"net"
+	"regexp"
+	"strings"
+)
+
+var domainParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// domainPattern 路由的域名规则，例如 {tenant}.example.com
+type domainPattern struct {
+	pattern string
+	regexp  *regexp.Regexp
+	params  []string
+}
+
+// Domain 约束路由或者路由组的域名，域名中的 {param} 会作为路由参数，例如 http.Domain("{tenant}.example.com")
+func Domain(pattern string) RouteOption {
+	var domain = newDomainPattern(pattern)
+	return func(attributes *attributes) {
+		attributes.domain = domain
+	}
+}
+
+func newDomainPattern(pattern string) *domainPattern {
+	var (
+		domain = &domainPattern{pattern: pattern, params: make([]string, 0)}
+		quoted = regexp.QuoteMeta(pattern)
+	)
+	for _, match := range domainParamPattern.FindAllStringSubmatch(pattern, -1) {
+		domain.params = append(domain.params, match[1])
+		quoted = strings.Replace(quoted, regexp.QuoteMeta(match[0]), "([^.]+)", 1)
+	}
+	domain.regexp = regexp.MustCompile("(?i)^" + quoted + "$")
+	return domain
+}
+
+// match 匹配请求的 host，返回域名中的参数值
+func (domain *domainPattern) match(host string) ([]string, bool) {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	var matches = domain.regexp.FindStringSubmatch(host)
+	if matches == nil {
+		return nil, false
+	}
+	return matches[1:], true
+}
+
+// build 用参数生成域名，used 记录用掉的参数，缺少参数时返回 MissingParamError
+func (domain *domainPattern) build(params contracts.Fields, used map[string]bool) (string, error) {
+	for _, name := range domain.params {
+		if value, exists := params[name]; !exists || value == nil {
+			return "", MissingParamError
+		}
+	}
+	return domainParamPattern.ReplaceAllStringFunc(domain.pattern, func(match string) string {
+		var name = match[1 : len(match)-1]
+		used[name] = true
+		return fmt.Sprint(params[name])
+	}), nil
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func serveHost(router *Router, host, path string) *httptest.ResponseRecorder {
+	var (
+		recorder = httptest.NewRecorder()
+		request  = httptest.NewRequest(http.MethodGet, path, nil)
+	)
+	request.Host = host
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestDomainRouting(t *testing.T) {
+	var router = newTestRouter()
+	router.Group("", Domain("{tenant}.example.com")).Get("/users/:id", func(request contracts.HttpRequest) string {
+		return request.Param("tenant") + " " + request.Param("id")
+	}, Name("tenant.users"))
+	router.Get("/users/:id", func(id string) string {
+		return "default " + id
+	}, Domain("api.example.com"))
+
+	for host, expected := range map[string]string{
+		"acme.example.com:8080": "acme 1",
+		"ACME.example.com":      "ACME 1",
+		"api.example.com":       "default 1",
+	} {
+		if body := serveHost(router, host, "/users/1").Body.String(); body != expected {
+			t.Errorf("%s: expected %q, got %q", host, expected, body)
+		}
+	}
+	for _, host := range []string{"example.com", "a.b.example.com", "acme.example.org"} {
+		if response := serveHost(router, host, "/users/1"); response.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", host, response.Code)
+		}
+	}
+}
+
+func TestDomainUrl(t *testing.T) {
+	var router = newTestRouter()
+	router.Group("", Domain("{tenant}.example.com")).Get("/users/:id", func() {}, Name("tenant.users"))
+	router.Get("/files/*", func() {}, Name("files"))
+	router.prepare()
+
+	for _, item := range []struct {
+		name     string
+		params   contracts.Fields
+		expected string
+		err      error
+	}{
+		{"tenant.users", contracts.Fields{"tenant": "acme", "id": 5, "page": 2}, "//acme.example.com/users/5?page=2", nil},
+		{"tenant.users", contracts.Fields{"id": 5}, "", MissingParamError},
+		{"tenant.users", contracts.Fields{"tenant": "acme"}, "", MissingParamError},
+		{"files", contracts.Fields{"*": "a/b.txt"}, "/files/a/b.txt", nil},
+		{"missing", nil, "", RouteNotFoundError},
+	} {
+		if result, err := router.Url(item.name, item.params); result != item.expected || err != item.err {
+			t.Errorf("%s %v: expected %q %v, got %q %v", item.name, item.params, item.expected, item.err, result, err)
+		}
+	}
+}
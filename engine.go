@@ -0,0 +1,105 @@
+package http
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+var (
+	EngineNotFoundError = errors.New("http engine is not registered")
+
+	engines = map[string]func() Engine{
+		"echo": NewEchoEngine,
+		"http": NewHttpEngine,
+	}
+)
+
+// Engine 路由引擎，负责匹配请求并调用处理器，路由器只通过该接口使用引擎，切换引擎不影响应用代码
+// 引擎只替换路由匹配和请求分发，请求上下文仍然是 echo.Context，Request 和 Response 也基于它，所以 echo 仍然是依赖
+// 未匹配的请求以 echo.ErrNotFound 或者 echo.ErrMethodNotAllowed 交给错误处理器
+type Engine interface {
+	http.Handler
+
+	// Add 注册路由，path 中可以包含 :param 和 *
+	Add(method, path string, handler echo.HandlerFunc)
+
+	// Use 添加引擎层的中间件，在路由匹配之后执行
+	Use(middlewares ...echo.MiddlewareFunc)
+
+	// SetMaxParam 设置路由参数的最大数量，包括域名中的参数
+	SetMaxParam(count int)
+
+	// SetErrorHandler 设置错误处理器
+	SetErrorHandler(handler echo.HTTPErrorHandler)
+
+	// SetRenderer 设置模板渲染器
+	SetRenderer(renderer echo.Renderer)
+
+	// SetDebug 设置调试模式，调试模式下默认的错误处理器会响应错误详情
+	SetDebug(debug bool)
+}
+
+// RegisterEngine 注册路由引擎，之后可以在 http.Config 的 Engine 中使用 name
+func RegisterEngine(name string, factory func() Engine) {
+	engines[name] = factory
+}
+
+// NewEngine 根据名称创建路由引擎，name 为空时使用 echo
+func NewEngine(name string) Engine {
+	if name == "" {
+		name = "echo"
+	}
+	var factory, exists = engines[name]
+	if !exists {
+		panic(EngineNotFoundError)
+	}
+	return factory()
+}
+
+// EchoEngine 基于 echo 的路由引擎
+type EchoEngine struct {
+	echo *echo.Echo
+}
+
+func NewEchoEngine() Engine {
+	return &EchoEngine{echo: echo.New()}
+}
+
+// Echo 获取底层的 echo 实例
+func (this *EchoEngine) Echo() *echo.Echo {
+	return this.echo
+}
+
+func (this *EchoEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	this.echo.ServeHTTP(w, r)
+}
+
+func (this *EchoEngine) Add(method, path string, handler echo.HandlerFunc) {
+	this.echo.Add(method, path, handler)
+}
+
+func (this *EchoEngine) Use(middlewares ...echo.MiddlewareFunc) {
+	this.echo.Use(middlewares...)
+}
+
+func (this *EchoEngine) SetMaxParam(count int) {
+	reserveParams(this.echo, count)
+}
+
+func (this *EchoEngine) SetErrorHandler(handler echo.HTTPErrorHandler) {
+	this.echo.HTTPErrorHandler = handler
+}
+
+func (this *EchoEngine) SetRenderer(renderer echo.Renderer) {
+	this.echo.Renderer = renderer
+}
+
+func (this *EchoEngine) SetDebug(debug bool) {
+	this.echo.Debug = debug
+}
+
+// reserveParams 扩大 echo 的最大参数数量，需要在处理请求之前调用，否则复用的上下文参数空间不足
+func reserveParams(instance *echo.Echo, count int) {
+	instance.NewContext(nil, nil).SetParamNames(make([]string, count)...)
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HttpEngine 基于 net/http 和内置前缀树路由的引擎，静态路径优先于参数，参数优先于 *
+type HttpEngine struct {
+	tree         *treeNode
+	factory      *echo.Echo // 只用来创建请求上下文，提供渲染器、参数绑定等功能
+	pool         sync.Pool
+	middlewares  []echo.MiddlewareFunc
+	errorHandler echo.HTTPErrorHandler
+}
+
+// treeNode 路由树的节点，每一段路径一个节点
+type treeNode struct {
+	path     string // 注册的完整路径，匹配后作为 context.Path()
+	static   map[string]*treeNode
+	param    *treeNode
+	any      *treeNode
+	handlers map[string]*treeRoute
+}
+
+type treeRoute struct {
+	handler echo.HandlerFunc
+	names   []string
+}
+
+func newTreeNode() *treeNode {
+	return &treeNode{static: make(map[string]*treeNode), handlers: make(map[string]*treeRoute)}
+}
+
+func NewHttpEngine() Engine {
+	var engine = &HttpEngine{
+		tree:    newTreeNode(),
+		factory: echo.New(),
+	}
+	engine.errorHandler = engine.factory.DefaultHTTPErrorHandler
+	engine.pool.New = func() interface{} {
+		return engine.factory.NewContext(nil, nil)
+	}
+	return engine
+}
+
+func (this *HttpEngine) Add(method, path string, handler echo.HandlerFunc) {
+	var (
+		node  = this.tree
+		names = make([]string, 0)
+	)
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			names = append(names, segment[1:])
+			if node.param == nil {
+				node.param = newTreeNode()
+			}
+			node = node.param
+		case segment == "*":
+			names = append(names, "*")
+			if node.any == nil {
+				node.any = newTreeNode()
+			}
+			node = node.any
+		default:
+			if node.static[segment] == nil {
+				node.static[segment] = newTreeNode()
+			}
+			node = node.static[segment]
+		}
+	}
+	node.path = path
+	node.handlers[method] = &treeRoute{handler: handler, names: names}
+	this.SetMaxParam(len(names))
+}
+
+// find 查找路径对应的节点，优先返回支持 method 的节点，allowed 为路径匹配但是方法不匹配的节点
+func (node *treeNode) find(method, path string, values []string, allowed **treeNode) (*treeNode, []string) {
+	var (
+		segment, rest = path, ""
+		last          = true
+	)
+	if index := strings.IndexByte(path, '/'); index >= 0 {
+		segment, rest, last = path[:index], path[index+1:], false
+	}
+
+	if child, exists := node.static[segment]; exists {
+		if found, result := child.match(method, rest, last, values, allowed); found != nil {
+			return found, result
+		}
+	}
+	if node.param != nil && segment != "" {
+		if found, result := node.param.match(method, rest, last, append(values, segment), allowed); found != nil {
+			return found, result
+		}
+	}
+	if node.any != nil {
+		if node.any.handlers[method] != nil {
+			return node.any, append(values, path)
+		}
+		if len(node.any.handlers) > 0 && *allowed == nil {
+			*allowed = node.any
+		}
+	}
+	return nil, values
+}
+
+func (node *treeNode) match(method, rest string, last bool, values []string, allowed **treeNode) (*treeNode, []string) {
+	if !last {
+		return node.find(method, rest, values, allowed)
+	}
+	if node.handlers[method] != nil {
+		return node, values
+	}
+	if len(node.handlers) > 0 && *allowed == nil {
+		*allowed = node
+	}
+	// "/files/*" 同样匹配 "/files/"
+	if node.any != nil && node.any.handlers[method] != nil {
+		return node.any, append(values, "")
+	}
+	return nil, values
+}
+
+func (this *HttpEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var context = this.pool.Get().(echo.Context)
+	context.Reset(r, w)
+	defer this.pool.Put(context)
+
+	var (
+		allowed      *treeNode
+		node, values = this.tree.find(r.Method, strings.TrimPrefix(echo.GetPath(r), "/"), context.ParamValues()[:0], &allowed)
+		handler      = echo.NotFoundHandler
+	)
+	switch {
+	case node != nil:
+		var route = node.handlers[r.Method]
+		context.SetPath(node.path)
+		context.SetParamNames(route.names...)
+		context.SetParamValues(values...)
+		handler = route.handler
+	case allowed != nil:
+		context.SetPath(allowed.path)
+		handler = echo.MethodNotAllowedHandler
+	}
+
+	for index := len(this.middlewares) - 1; index >= 0; index-- {
+		handler = this.middlewares[index](handler)
+	}
+	if err := handler(context); err != nil {
+		this.errorHandler(err, context)
+	}
+}
+
+func (this *HttpEngine) Use(middlewares ...echo.MiddlewareFunc) {
+	this.middlewares = append(this.middlewares, middlewares...)
+}
+
+func (this *HttpEngine) SetMaxParam(count int) {
+	reserveParams(this.factory, count)
+}
+
+func (this *HttpEngine) SetErrorHandler(handler echo.HTTPErrorHandler) {
+	this.errorHandler = handler
+}
+
+func (this *HttpEngine) SetRenderer(renderer echo.Renderer) {
+	this.factory.Renderer = renderer
+}
+
+func (this *HttpEngine) SetDebug(debug bool) {
+	this.factory.Debug = debug
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"fmt"
+	"github.com/goal-web/contracts"
+	"net/http"
+	"testing"
+)
+
+// testEngines 两个内置引擎的路由行为应该一致
+var testEngines = map[string]func() Engine{
+	"echo": NewEchoEngine,
+	"http": NewHttpEngine,
+}
+
+func TestEngineRouting(t *testing.T) {
+	for name, factory := range testEngines {
+		var router = newTestRouter(factory())
+		router.Get("/users/:id", func(request contracts.HttpRequest) string { return "user " + request.Param("id") })
+		router.Get("/users/new", func() string { return "new user" })
+		router.Post("/users/:id", func(id int) string { return fmt.Sprint(id) })
+		router.Get("/users/:user/posts/:post", func(request contracts.HttpRequest) string {
+			return request.Param("user") + " " + request.Param("post")
+		})
+		router.Get("/files/*", func(request contracts.HttpRequest) string { return "file " + request.Param("*") })
+		router.Get("/posts/:id", func(request contracts.HttpRequest) string { return "number " + request.Param("id") }, WhereNumber("id"))
+		router.Get("/posts/:slug", func(request contracts.HttpRequest) string { return "slug " + request.Param("slug") })
+
+		for _, item := range []struct {
+			method, path string
+			code         int
+			body, allow  string
+		}{
+			{http.MethodGet, "/users/1", http.StatusOK, "user 1", ""},
+			{http.MethodGet, "/users/new", http.StatusOK, "new user", ""},
+			{http.MethodPost, "/users/7", http.StatusOK, "7", ""},
+			{http.MethodGet, "/users/1/posts/2", http.StatusOK, "1 2", ""},
+			{http.MethodGet, "/files/docs/readme.md", http.StatusOK, "file docs/readme.md", ""},
+			{http.MethodGet, "/posts/12", http.StatusOK, "number 12", ""},
+			{http.MethodGet, "/posts/hello", http.StatusOK, "slug hello", ""},
+			{http.MethodHead, "/users/1", http.StatusOK, "", ""},
+			{http.MethodDelete, "/users/1", http.StatusMethodNotAllowed, "", "GET, POST, HEAD, OPTIONS"},
+			{http.MethodGet, "/missing", http.StatusNotFound, "", ""},
+			{http.MethodGet, "/users", http.StatusNotFound, "", ""},
+		} {
+			var response = serve(router, item.method, item.path)
+			if response.Code != item.code || (item.body != "" && response.Body.String() != item.body) || response.Header().Get("Allow") != item.allow {
+				t.Errorf("%s engine %s %s: unexpected response %d %q with Allow %q",
+					name, item.method, item.path, response.Code, response.Body.String(), response.Header().Get("Allow"))
+			}
+		}
+	}
+}
+
+func TestEngineDomainParams(t *testing.T) {
+	for name, factory := range testEngines {
+		var router = newTestRouter(factory())
+		router.Get("/users/:id", func(request contracts.HttpRequest) string {
+			return request.Param("tenant") + " " + request.Param("id")
+		}, Domain("{tenant}.example.com"))
+
+		if body := serveHost(router, "acme.example.com", "/users/1").Body.String(); body != "acme 1" {
+			t.Errorf("%s engine: unexpected response %q", name, body)
+		}
+	}
+}
+
+func TestUnknownEngine(t *testing.T) {
+	defer func() {
+		if err := recover(); err != EngineNotFoundError {
+			t.Fatalf("expected EngineNotFoundError, got %v", err)
+		}
+	}()
+	NewEngine("missing")
+}
@@ -1,5 +1,13 @@
 package http
 
+// ServeStarted 所有监听器绑定完成并开始服务
+type ServeStarted struct {
+}
+
+func (this *ServeStarted) Event() string {
+	return "HTTP_SERVE_STARTED"
+}
+
 type ServeClosed struct {
 }
 
@@ -2,6 +2,7 @@ package http
 
 import (
 	"github.com/goal-web/contracts"
+	"net/http"
 )
 
 type Exception struct {
@@ -17,3 +18,13 @@ func (this Exception) Fields() contracts.Fields {
 		"fields": this.Request.Fields(),
 	}
 }
+
+// Status 获取异常对应的 http 状态码，默认为 500
+func (this Exception) Status() int {
+	if this.Exception != nil {
+		if status, ok := this.Exception.Fields()["status"].(int); ok && status > 0 {
+			return status
+		}
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/exceptions"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+// FallbackRegistrar 可以注册兜底路由的路由组，NewGroup 和 Router.Group 返回的路由组都实现了该接口
+type FallbackRegistrar interface {
+	contracts.RouteGroup
+
+	// Fallback 设置路由组的兜底路由，组内没有匹配的路由时由兜底路由处理
+	Fallback(handler interface{}, middlewares ...interface{}) contracts.RouteGroup
+}
+
+// Fallback 设置路由组的兜底路由，例如 /app 下的单页应用或者 /api 下的 json 404，未匹配的路径可以通过 "*" 参数获取
+func (group *group) Fallback(handler interface{}, middlewares ...interface{}) contracts.RouteGroup {
+	group.fallback = newRoute(nil, group.prefix+"/*", handler, middlewares...)
+	return group
+}
+
+// Fallback 设置全局兜底路由，没有任何路由匹配时由兜底路由处理，路由组的兜底路由优先
+func (this *Router) Fallback(handler interface{}, middlewares ...interface{}) {
+	this.fallback = newRoute(nil, "/*", handler, middlewares...)
+}
+
+// findFallback 查找前缀和域名匹配当前请求的兜底路由
+func (this *Router) findFallback(context echo.Context) *mountedRoute {
+	var path = context.Request().URL.Path
+	for _, fallback := range this.fallbacks {
+		var prefix = strings.TrimSuffix(fallback.path, "/*")
+		if prefix != "" && path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		context.SetParamNames("*")
+		context.SetParamValues(strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/"))
+		if fallback.matches(context, "") {
+			return fallback
+		}
+	}
+	return nil
+}
+
+// options 自动响应 OPTIONS 请求的路由，经过全局中间件，以中间件实现的跨域可以处理预检请求
+func (this *Router) options(path, normalized string) *mountedRoute {
+	return this.compileRoute(newRoute([]string{echo.OPTIONS}, path, func(request *Request) error {
+		request.Response().Header().Set(echo.HeaderAllow, strings.Join(this.allowed[normalized], ", "))
+		return request.NoContent(http.StatusNoContent)
+	}), nil, nil)
+}
+
+// handleError 处理请求中的错误，未匹配的路由优先交给兜底路由处理，405 响应带上 Allow 头
+func (this *Router) handleError(err error, context echo.Context) {
+	var status = context.Response().Status
+	if httpError, isHttpError := err.(*echo.HTTPError); isHttpError && !context.Response().Committed {
+		status = httpError.Code
+		switch httpError.Code {
+		case http.StatusNotFound:
+			if fallback := this.findFallback(context); fallback != nil {
+				this.handle(fallback, context)
+				return
+			}
+		case http.StatusMethodNotAllowed:
+			context.Response().Header().Set(echo.HeaderAllow, strings.Join(this.allowed[normalizePath(context.Path())], ", "))
+		}
+	}
+
+	request := this.newRequest(context)
+	if result := this.app.StaticCall(exceptionHandler, Exception{Exception: exceptions.WithError(err, contracts.Fields{
+		"status": status,
+	}), Request: request})[0]; result != nil {
+		HandleResponse(result, request)
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"net/http"
+	"testing"
+)
+
+func TestFallbacksAndAllowedMethods(t *testing.T) {
+	var router = newTestRouter()
+	router.Use(func(request *Request, next contracts.Pipe) interface{} {
+		request.Response().Header().Set("Access-Control-Allow-Origin", "*")
+		return next(request)
+	})
+	router.Get("/users/:id", func() string { return "show" }, WhereNumber("id"))
+	router.Post("/users/:id", func() string { return "update" })
+	router.Group("/app").(FallbackRegistrar).Fallback(func(request contracts.HttpRequest) string {
+		return "app " + request.Param("*")
+	})
+	router.Fallback(func() string { return "global" })
+
+	for _, item := range []struct {
+		method, path string
+		code         int
+		body, allow  string
+	}{
+		{http.MethodGet, "/users/1", http.StatusOK, "show", ""},
+		{http.MethodHead, "/users/1", http.StatusOK, "", ""},
+		{http.MethodOptions, "/users/1", http.StatusNoContent, "", "GET, POST, HEAD, OPTIONS"},
+		{http.MethodDelete, "/users/1", http.StatusMethodNotAllowed, "", "GET, POST, HEAD, OPTIONS"},
+		{http.MethodGet, "/users/abc", http.StatusOK, "global", ""},
+		{http.MethodGet, "/app/settings/profile", http.StatusOK, "app settings/profile", ""},
+		{http.MethodGet, "/app", http.StatusOK, "app ", ""},
+		{http.MethodGet, "/missing", http.StatusOK, "global", ""},
+	} {
+		var response = serve(router, item.method, item.path)
+		if response.Code != item.code || (item.body != "" && response.Body.String() != item.body) || response.Header().Get("Allow") != item.allow {
+			t.Errorf("%s %s: unexpected response %d %q with Allow %q", item.method, item.path, response.Code, response.Body.String(), response.Header().Get("Allow"))
+		}
+	}
+	if response := serve(router, http.MethodOptions, "/users/1"); response.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("automatic OPTIONS must run global middlewares")
+	}
+}
+
+func TestNotFoundWithoutFallback(t *testing.T) {
+	var router = newTestRouter()
+	router.Get("/users", func() string { return "users" })
+
+	if response := serve(router, http.MethodGet, "/missing"); response.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", response.Code)
+	}
+}
@@ -8,7 +8,9 @@ require (
 	github.com/goal-web/pipeline v0.1.6
 	github.com/goal-web/supports v0.1.22
 	github.com/goal-web/validation v0.1.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/labstack/echo/v4 v4.6.3
+	golang.org/x/net v0.17.0
 )
 
 require (
@@ -23,8 +25,7 @@ require (
 	github.com/pkg/errors v0.8.1 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasttemplate v1.2.1 // indirect
-	golang.org/x/crypto v0.0.0-20220112180741-5e0467b6c7ce // indirect
-	golang.org/x/net v0.0.0-20211112202133-69e39bad7dc2 // indirect
-	golang.org/x/sys v0.0.0-20211103235746-7861aae1554b // indirect
-	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/crypto v0.14.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
 )
@@ -2,7 +2,6 @@ package http
 
 import (
 	"errors"
-	"github.com/goal-web/container"
 	"github.com/goal-web/contracts"
 	"github.com/labstack/echo/v4"
 )
@@ -12,18 +11,23 @@ var (
 )
 
 type group struct {
+	attributes
 	prefix      string
 	middlewares []contracts.MagicalFunc
 	routes      []contracts.Route
 	groups      []contracts.RouteGroup
+	fallback    *route
 }
 
+// NewGroup 创建路由组，middlewares 中可以混入 RouteOption，例如 WhereNumber("id")
 func NewGroup(prefix string, middlewares ...interface{}) contracts.RouteGroup {
+	var pipes, options = parseRouteArgs(middlewares...)
 	return &group{
+		attributes:  newAttributes(options),
 		prefix:      prefix,
 		routes:      make([]contracts.Route, 0),
 		groups:      make([]contracts.RouteGroup, 0),
-		middlewares: convertToMiddlewares(middlewares...),
+		middlewares: pipes,
 	}
 }
 
@@ -34,7 +38,7 @@ func (group *group) AddRoute(route contracts.Route) contracts.RouteGroup {
 	return group
 }
 
-// Group 添加一个子组
+// Group 添加一个子组，子组继承父组的中间件（可以通过 WithoutInheritedMiddleware 关闭）、名称前缀、域名和约束
 func (group *group) Group(prefix string, middlewares ...interface{}) contracts.RouteGroup {
 	var groupInstance = NewGroup(group.prefix+prefix, middlewares...)
 
@@ -43,7 +47,7 @@ func (group *group) Group(prefix string, middlewares ...interface{}) contracts.R
 	return groupInstance
 }
 
-// Add 添加路由，method 只允许字符串或者字符串数组
+// Add 添加路由，method 只允许字符串或者字符串数组，handler 可以是函数或者 "Controller@Method"
 func (group *group) Add(method interface{}, path string, handler interface{}, middlewares ...interface{}) contracts.RouteGroup {
 	methods := make([]string, 0)
 	switch r := method.(type) {
@@ -54,12 +58,7 @@ func (group *group) Add(method interface{}, path string, handler interface{}, mi
 	default:
 		panic(MethodTypeError)
 	}
-	group.AddRoute(&route{
-		method:      methods,
-		path:        group.prefix + path,
-		middlewares: convertToMiddlewares(middlewares...),
-		handler:     container.NewMagicalFunc(handler),
-	})
+	group.AddRoute(newRoute(methods, group.prefix+path, handler, middlewares...))
 
 	return group
 }
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// testTrace 按执行顺序记录中间件
+func testTrace(name string) func(request *Request, next contracts.Pipe) interface{} {
+	return func(request *Request, next contracts.Pipe) interface{} {
+		request.Response().Header().Add("X-Trace", name)
+		return next(request)
+	}
+}
+
+func TestNestedGroupInheritance(t *testing.T) {
+	var router = newTestRouter()
+	router.AliasMiddleware("auth", testTrace("auth"))
+	router.AliasMiddleware("log", testTrace("log"))
+
+	var admin = router.Group("/admin", "auth", Name("admin."), WhereNumber("id"), Domain("admin.example.com"))
+	admin.Group("/users", "log", Name("users.")).Get("/:id", func(id int) int { return id }, Name("show"))
+	admin.Group("/public", WithoutInheritedMiddleware(), Name("public.")).Get("/status", func() string { return "ok" }, Name("status"))
+	router.prepare()
+
+	for _, item := range []struct {
+		path, trace string
+		code        int
+	}{
+		{"/admin/users/3", "auth,log", http.StatusOK},
+		{"/admin/users/abc", "", http.StatusNotFound},
+		{"/admin/public/status", "", http.StatusOK},
+	} {
+		var response = serveHost(router, "admin.example.com", item.path)
+		if trace := strings.Join(response.Header().Values("X-Trace"), ","); response.Code != item.code || trace != item.trace {
+			t.Errorf("%s: expected %d with trace %q, got %d with %q", item.path, item.code, item.trace, response.Code, trace)
+		}
+	}
+	if response := serveHost(router, "example.com", "/admin/users/3"); response.Code != http.StatusNotFound {
+		t.Errorf("nested group must inherit the domain, got %d", response.Code)
+	}
+
+	if result, err := router.Url("admin.users.show", contracts.Fields{"id": 3}); err != nil || result != "//admin.example.com/admin/users/3" {
+		t.Errorf("unexpected url %q %v", result, err)
+	}
+	if result, err := router.Url("admin.public.status"); err != nil || result != "//admin.example.com/admin/public/status" {
+		t.Errorf("isolated group must keep the name prefix and domain, got %q %v", result, err)
+	}
+}
+
+func TestNestedVersionGroup(t *testing.T) {
+	var router = newTestRouter()
+	router.Version("v2", "/api", testTrace("api")).Group("/things", testTrace("things")).Get("/", func() string { return "things" })
+
+	for _, path := range []string{"/api/v2/things/", "/api/things/"} {
+		var response = serve(router, http.MethodGet, path)
+		if response.Body.String() != "things" || strings.Join(response.Header().Values("X-Trace"), ",") != "api,things" {
+			t.Errorf("%s: unexpected response %q with trace %v", path, response.Body.String(), response.Header().Values("X-Trace"))
+		}
+	}
+}
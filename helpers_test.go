@@ -0,0 +1,49 @@
+package http
+
+import (
+	"github.com/goal-web/container"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/utils"
+	"net/http/httptest"
+	"reflect"
+)
+
+// testApplication 只提供容器的应用，用于在测试中创建路由器
+type testApplication struct {
+	contracts.Container
+}
+
+func (testApplication) GetExceptionHandler() contracts.ExceptionHandler {
+	return testExceptionHandler{}
+}
+func (testApplication) IsProduction() bool                            { return false }
+func (testApplication) Debug() bool                                   { return false }
+func (testApplication) Environment() string                           { return "testing" }
+func (testApplication) RegisterServices(...contracts.ServiceProvider) {}
+func (testApplication) Start() map[string]error                       { return nil }
+func (testApplication) Stop()                                         {}
+
+type testEvents struct{}
+
+func (testEvents) Register(string, contracts.EventListener) {}
+func (testEvents) Dispatch(contracts.Event)                 {}
+
+// testExceptionHandler 把异常原样作为响应
+type testExceptionHandler struct{}
+
+func (testExceptionHandler) Handle(exception contracts.Exception) interface{} { return exception }
+func (testExceptionHandler) ShouldReport(contracts.Exception) bool            { return false }
+func (testExceptionHandler) Report(contracts.Exception)                       {}
+
+func newTestRouter(engine ...Engine) *Router {
+	var app = testApplication{container.New()}
+	app.Instance("events", testEvents{})
+	app.Instance(utils.GetTypeKey(reflect.TypeOf((*contracts.ExceptionHandler)(nil)).Elem()), testExceptionHandler{})
+	return New(app, engine...).(*Router)
+}
+
+func serve(router *Router, method, path string) *httptest.ResponseRecorder {
+	var recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	return recorder
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+// anyMethods Handle 和 Mount 注册的方法，HEAD 在装配时自动添加，OPTIONS 交给 handler 自己响应
+var anyMethods = []string{
+	echo.GET, echo.POST, echo.PUT, echo.PATCH, echo.DELETE, echo.OPTIONS, echo.CONNECT, echo.TRACE, echo.PROPFIND, echo.REPORT,
+}
+
+// WrapHandler 把标准库的 http.Handler 转换成路由处理器，例如 router.Get("/metrics", http.WrapHandler(promhttp.Handler()))
+func WrapHandler(handler http.Handler) func(request *Request) interface{} {
+	return func(request *Request) interface{} {
+		handler.ServeHTTP(request.Response(), request.Request())
+		return nil
+	}
+}
+
+// WrapMiddleware 把 func(http.Handler) http.Handler 形式的标准库中间件转换成路由中间件
+// 后续的中间件和处理器在标准库中间件提供的 ResponseWriter 和 *http.Request 上执行，并在其中写入响应
+func WrapMiddleware(middleware func(http.Handler) http.Handler) func(request *Request, next contracts.Pipe) interface{} {
+	return func(request *Request, next contracts.Pipe) interface{} {
+		var (
+			response = request.Response()
+			writer   = response.Writer
+			original = request.Request()
+		)
+		defer func() {
+			response.Writer = writer
+			request.SetRequest(original)
+		}()
+
+		middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			response.Writer = w
+			request.SetRequest(r)
+			HandleResponse(next(request), request)
+		})).ServeHTTP(writer, original)
+
+		return nil
+	}
+}
+
+// ServeHTTP 实现 http.Handler，第一次调用时装配路由，可以把 goal 的路由挂载到其他服务器或者测试中使用
+func (this *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	this.prepare()
+	this.engine.ServeHTTP(w, r)
+}
+
+// Handle 把标准库的 http.Handler 注册为 path 上所有方法的路由，路由中间件和全局中间件同样生效
+func (this *Router) Handle(path string, handler http.Handler, middlewares ...interface{}) {
+	this.Add(anyMethods, path, WrapHandler(handler), middlewares...)
+}
+
+// Mount 把标准库的 http.Handler 挂载到 prefix 下，请求交给 handler 之前会去掉 prefix，例如 router.Mount("/debug/pprof", mux)
+func (this *Router) Mount(prefix string, handler http.Handler, middlewares ...interface{}) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	var stripped = WrapHandler(stripPrefix(prefix, handler))
+	this.Add(anyMethods, prefix, stripped, middlewares...)
+	this.Add(anyMethods, prefix+"/*", stripped, middlewares...)
+}
+
+// stripPrefix 和 http.StripPrefix 类似，但是去掉前缀后的路径总是以 / 开头
+func stripPrefix(prefix string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			request = r.Clone(r.Context())
+			path    = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		)
+		request.URL.Path = path
+		if r.URL.RawPath != "" {
+			request.URL.RawPath = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.RawPath, prefix), "/")
+		}
+		handler.ServeHTTP(w, request)
+	})
+}
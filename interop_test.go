@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testContextKey struct{}
+
+func TestMountAndHandle(t *testing.T) {
+	var (
+		router = newTestRouter()
+		mux    = http.NewServeMux()
+	)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("mux " + r.URL.Path))
+	})
+	router.Mount("/debug/", mux)
+	router.Handle("/echo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path))
+	}), testTrace("route"))
+
+	for _, item := range []struct {
+		method, path, expected string
+	}{
+		{http.MethodGet, "/debug", "mux /"},
+		{http.MethodGet, "/debug/pprof/heap", "mux /pprof/heap"},
+		{http.MethodPost, "/debug/vars", "mux /vars"},
+		{http.MethodPut, "/echo", "PUT /echo"},
+	} {
+		if body := serve(router, item.method, item.path).Body.String(); body != item.expected {
+			t.Errorf("%s %s: expected %q, got %q", item.method, item.path, item.expected, body)
+		}
+	}
+	if trace := serve(router, http.MethodGet, "/echo").Header().Get("X-Trace"); trace != "route" {
+		t.Errorf("route middlewares must run for handled routes, got %q", trace)
+	}
+}
+
+func TestStandardMiddleware(t *testing.T) {
+	var (
+		router   = newTestRouter()
+		standard = func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Standard", "before")
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), testContextKey{}, "value")))
+			})
+		}
+	)
+	router.Get("/std", func(request *Request) string {
+		var value, _ = request.Request().Context().Value(testContextKey{}).(string)
+		return "goal " + value
+	}, standard)
+
+	var response = serveRecorder(router, httptest.NewRequest(http.MethodGet, "/std", nil))
+	if response.Body.String() != "goal value" || response.Header().Get("X-Standard") != "before" {
+		t.Fatalf("unexpected response %q with header %q", response.Body.String(), response.Header().Get("X-Standard"))
+	}
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultListener 默认监听器的名称，监听 Router.Start 的地址
+const DefaultListener = "default"
+
+type listenerContextKey struct{}
+
+var (
+	ListenerNetworkError = errors.New("listener network is not supported")
+	SystemdListenerError = errors.New("systemd socket is not passed to this process")
+	FileDescriptorError  = errors.New("invalid file descriptor")
+	NoListenerError      = errors.New("no address or listener is configured")
+	UnixSocketInUseError = errors.New("unix socket is in use by another process")
+
+	// systemd 和热重启传递的文件描述符从 3 开始，0、1、2 为标准输入输出
+	listenFdsStart = 3
+)
+
+// ListenerConfig 监听器配置
+type ListenerConfig struct {
+	// Network 可选 tcp（默认）、tcp4、tcp6、unix、systemd 或者 fd
+	Network string
+
+	// Address tcp 地址、unix socket 路径、systemd 的 FileDescriptorName 或者序号、继承的文件描述符
+	Address string
+
+	// Mode unix socket 文件的权限，为 0 时不修改
+	Mode os.FileMode
+}
+
+// Listener 把路由或者路由组绑定到指定的监听器上，没有绑定的路由在所有监听器上都可以访问
+// 例如管理后台只在 admin 端口上提供：router.Group("/admin", http.Listener("admin"))
+func Listener(names ...string) RouteOption {
+	return func(attributes *attributes) {
+		attributes.listeners = append(attributes.listeners, names...)
+	}
+}
+
+// ListenerOf 获取接收请求的监听器名称，不是通过 Router.Start 启动的服务返回 DefaultListener
+func ListenerOf(request *http.Request) string {
+	if name, ok := request.Context().Value(listenerContextKey{}).(string); ok {
+		return name
+	}
+	return DefaultListener
+}
+
+// listen 根据配置创建监听器
+func listen(config ListenerConfig) (net.Listener, error) {
+	switch config.Network {
+	case "":
+		return net.Listen("tcp", config.Address)
+	case "tcp", "tcp4", "tcp6":
+		return net.Listen(config.Network, config.Address)
+	case "unix":
+		return listenUnix(config)
+	case "systemd":
+		return listenSystemd(config.Address)
+	case "fd":
+		var fd, err = strconv.Atoi(config.Address)
+		if err != nil {
+			return nil, err
+		}
+		return listenFd(fd, "fd:"+config.Address)
+	}
+	return nil, ListenerNetworkError
+}
+
+// listenUnix 监听 unix socket，之前遗留的 socket 文件没有进程在监听时才会被删除
+func listenUnix(config ListenerConfig) (net.Listener, error) {
+	if info, err := os.Stat(config.Address); err == nil && info.Mode()&os.ModeSocket != 0 {
+		var conn, dialErr = net.DialTimeout("unix", config.Address, time.Second)
+		if dialErr == nil {
+			_ = conn.Close()
+			return nil, UnixSocketInUseError
+		}
+		if !errors.Is(dialErr, syscall.ECONNREFUSED) {
+			return nil, dialErr
+		}
+		if err = os.Remove(config.Address); err != nil {
+			return nil, err
+		}
+	}
+	var listener, err = net.Listen("unix", config.Address)
+	if err != nil {
+		return nil, err
+	}
+	if config.Mode != 0 {
+		if err = os.Chmod(config.Address, config.Mode); err != nil {
+			_ = listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+// listenSystemd 使用 systemd socket activation 传递的文件描述符，name 可以是 FileDescriptorName 或者序号
+func listenSystemd(name string) (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil, SystemdListenerError
+	}
+	var count, err = strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return nil, SystemdListenerError
+	}
+
+	var names = strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for index := 0; index < count; index++ {
+		if strconv.Itoa(index) == name || (index < len(names) && names[index] == name) {
+			return listenFd(listenFdsStart+index, "systemd:"+name)
+		}
+	}
+	return nil, SystemdListenerError
+}
+
+// listenFd 使用继承的文件描述符创建监听器
+func listenFd(fd int, name string) (net.Listener, error) {
+	var file = os.NewFile(uintptr(fd), name)
+	if file == nil {
+		return nil, FileDescriptorError
+	}
+	defer file.Close()
+	return net.FileListener(file)
+}
+
+// listenerContext 把监听器名称保存在每个连接的 context 中
+func listenerContext(name string) func(net.Listener) context.Context {
+	return func(net.Listener) context.Context {
+		return context.WithValue(context.Background(), listenerContextKey{}, name)
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func getBody(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	var response, err = client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, string(body)
+}
+
+func TestMultipleListenersAndRouteBinding(t *testing.T) {
+	var router = newTestRouter()
+	router.Get("/where", func(request *Request) string { return ListenerOf(request.Request()) })
+	router.Group("/admin", Listener("admin")).Get("/status", func() string { return "admin" })
+
+	var (
+		address = startTestServer(t, router, Config{Listeners: map[string]ListenerConfig{
+			"admin": {Address: "127.0.0.1:0"},
+		}})
+		admin = waitListener(t, router, "admin").Addr().String()
+	)
+
+	for _, item := range []struct {
+		url, body string
+		code      int
+	}{
+		{"http://" + address + "/where", DefaultListener, http.StatusOK},
+		{"http://" + admin + "/where", "admin", http.StatusOK},
+		{"http://" + admin + "/admin/status", "admin", http.StatusOK},
+		{"http://" + address + "/admin/status", "", http.StatusNotFound},
+	} {
+		if code, body := getBody(t, http.DefaultClient, item.url); code != item.code || (item.body != "" && body != item.body) {
+			t.Errorf("%s: expected %d %q, got %d %q", item.url, item.code, item.body, code, body)
+		}
+	}
+}
+
+func TestUnixSocketListener(t *testing.T) {
+	var (
+		path   = filepath.Join(t.TempDir(), "http.sock")
+		router = newTestRouter()
+	)
+	router.Get("/where", func(request *Request) string { return ListenerOf(request.Request()) })
+	router.Configure(Config{Listeners: map[string]ListenerConfig{
+		"socket": {Network: "unix", Address: path, Mode: 0600},
+	}})
+	go func() { _ = router.Start("") }()
+	t.Cleanup(func() { _ = router.Close() })
+	waitListener(t, router, "socket")
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected socket file %v %v", info, err)
+	}
+	var client = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	if code, body := getBody(t, client, "http://unix/where"); code != http.StatusOK || body != "socket" {
+		t.Fatalf("unexpected response %d %q", code, body)
+	}
+}
+
+func TestUnixSocketInUse(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "http.sock")
+	var live, err = net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = listen(ListenerConfig{Network: "unix", Address: path}); err != UnixSocketInUseError {
+		t.Fatalf("a live socket must not be replaced, got %v", err)
+	}
+
+	// 进程异常退出后遗留的 socket 文件可以被替换
+	live.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = live.Close()
+	stale, err := listen(ListenerConfig{Network: "unix", Address: path})
+	if err != nil {
+		t.Fatalf("stale socket is not replaced: %v", err)
+	}
+	_ = stale.Close()
+}
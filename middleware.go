@@ -0,0 +1,288 @@
+package http
+
+import (
+	"errors"
+	"github.com/goal-web/container"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/exceptions"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+var (
+	UnresolvedMiddlewareError = errors.New("named middleware must be resolved by the router before it is called")
+	AnonymousMiddlewareError  = errors.New("closures and method values can not be excluded by value, exclude them by alias") // 同一个工厂返回的闭包函数名相同，无法区分
+
+	anonymousFunction = regexp.MustCompile(`\.func\d+(\.\d+)*$|-fm$`)
+
+	resultType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// MiddlewareParams 中间件名称中冒号后面的参数，例如 "throttle:60,1" 中的 60 和 1，中间件可以通过参数注入获取
+type MiddlewareParams []string
+
+// middlewareReference 以名称引用的中间件，例如 "auth"、"throttle:60,1" 或者中间件组 "api"，装配路由时展开
+type middlewareReference struct {
+	name   string
+	params MiddlewareParams
+}
+
+func parseMiddlewareReference(value string) *middlewareReference {
+	var reference = &middlewareReference{name: value}
+	if index := strings.Index(value, ":"); index > 0 {
+		reference.name = value[:index]
+		for _, param := range strings.Split(value[index+1:], ",") {
+			reference.params = append(reference.params, strings.TrimSpace(param))
+		}
+	}
+	return reference
+}
+
+func (reference *middlewareReference) NumOut() int {
+	return 1
+}
+
+func (reference *middlewareReference) NumIn() int {
+	return 0
+}
+
+func (reference *middlewareReference) Call([]reflect.Value) []reflect.Value {
+	panic(UnresolvedMiddlewareError)
+}
+
+func (reference *middlewareReference) Arguments() []reflect.Type {
+	return nil
+}
+
+func (reference *middlewareReference) Returns() []reflect.Type {
+	return []reflect.Type{resultType}
+}
+
+// namedMiddleware 展开后的命名中间件，调用时注入 MiddlewareParams
+type namedMiddleware struct {
+	contracts.MagicalFunc
+	name       string
+	function   string // 中间件的函数名，通过函数排除中间件时也会排除同一函数的别名，闭包没有函数名
+	params     MiddlewareParams
+	terminable TerminableMiddleware
+}
+
+// anonymous 没有注册别名的闭包或者方法值，只能按实例区分
+func (named *namedMiddleware) anonymous() bool {
+	return named.function == "" && anonymousFunction.MatchString(named.name)
+}
+
+// AliasMiddleware 注册中间件别名，之后可以在任何接受中间件的地方使用名称，例如 "auth" 或者带参数的 "throttle:60,1"
+func (this *Router) AliasMiddleware(name string, middleware interface{}) {
+	this.aliases[name] = convertToMiddlewares(middleware)[0]
+}
+
+// MiddlewareGroup 注册中间件组，组内可以是中间件函数、别名或者其他中间件组，例如 router.MiddlewareGroup("api", "throttle:60,1", "auth")
+func (this *Router) MiddlewareGroup(name string, middlewares ...interface{}) {
+	this.middlewareGroups[name] = convertToMiddlewares(middlewares...)
+}
+
+// MiddlewarePriority 设置命名中间件的执行顺序，列表中的中间件无论注册顺序如何都按此顺序执行，其他中间件的位置不变
+func (this *Router) MiddlewarePriority(names ...string) {
+	this.priority = names
+}
+
+// resolveMiddlewares 展开中间件列表中的别名和中间件组，别名可以指向其他别名或者中间件组，循环引用时 panic
+func (this *Router) resolveMiddlewares(middlewares []contracts.MagicalFunc) []contracts.MagicalFunc {
+	return this.sortMiddlewares(this.expandMiddlewares(middlewares, nil))
+}
+
+// expandMiddlewares 递归展开中间件，resolving 是正在展开的名称链，用于检测循环引用
+func (this *Router) expandMiddlewares(middlewares []contracts.MagicalFunc, resolving []string) []contracts.MagicalFunc {
+	var resolved = make([]contracts.MagicalFunc, 0, len(middlewares))
+	for _, middleware := range middlewares {
+		var reference, isReference = middleware.(*middlewareReference)
+		if !isReference {
+			resolved = append(resolved, middleware)
+			continue
+		}
+		if contains(resolving, reference.name) {
+			panic(exceptions.New("middleware references itself", contracts.Fields{
+				"middleware": strings.Join(append(resolving, reference.name), " -> "),
+			}))
+		}
+		var chain = append(resolving[:len(resolving):len(resolving)], reference.name)
+		if group, isGroup := this.middlewareGroups[reference.name]; isGroup {
+			resolved = append(resolved, this.expandMiddlewares(group, chain)...)
+			continue
+		}
+		var alias, isAlias = this.aliases[reference.name]
+		if !isAlias {
+			panic(exceptions.New("middleware is not registered", contracts.Fields{
+				"middleware": reference.name,
+			}))
+		}
+		if target, isTarget := alias.(*middlewareReference); isTarget { // 指向其他别名或者中间件组的别名
+			var expanded = this.expandMiddlewares([]contracts.MagicalFunc{target}, chain)
+			if len(expanded) == 1 {
+				alias = expanded[0]
+			} else {
+				resolved = append(resolved, expanded...)
+				continue
+			}
+		}
+		var named = &namedMiddleware{MagicalFunc: alias, name: reference.name, params: reference.params}
+		if function, isFunction := alias.(*namedMiddleware); isFunction {
+			named.MagicalFunc, named.function, named.terminable = function.MagicalFunc, function.function, function.terminable
+			if len(named.params) == 0 {
+				named.params = function.params
+			}
+		}
+		resolved = append(resolved, named)
+	}
+	return resolved
+}
+
+// sortMiddlewares 按优先级调整命名中间件的顺序，只在它们原来占据的位置之间调整
+func (this *Router) sortMiddlewares(middlewares []contracts.MagicalFunc) []contracts.MagicalFunc {
+	if len(this.priority) == 0 {
+		return middlewares
+	}
+	var (
+		positions   = make([]int, 0)
+		prioritized = make([]contracts.MagicalFunc, 0)
+	)
+	for index, middleware := range middlewares {
+		if this.priorityOf(middleware) >= 0 {
+			positions = append(positions, index)
+			prioritized = append(prioritized, middleware)
+		}
+	}
+	sort.SliceStable(prioritized, func(i, j int) bool {
+		return this.priorityOf(prioritized[i]) < this.priorityOf(prioritized[j])
+	})
+	for index, position := range positions {
+		middlewares[position] = prioritized[index]
+	}
+	return middlewares
+}
+
+func (this *Router) priorityOf(middleware contracts.MagicalFunc) int {
+	if named, isNamed := middleware.(*namedMiddleware); isNamed && !named.anonymous() {
+		for index, name := range this.priority {
+			if name == named.name {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+// newMagicalMiddleware 转换中间件，字符串作为命名中间件的引用，函数以函数名作为名称，TerminableMiddleware 以类型名作为名称
+// func(http.Handler) http.Handler 形式的标准库中间件会通过 WrapMiddleware 转换
+func newMagicalMiddleware(middleware interface{}) contracts.MagicalFunc {
+	if name, isName := middleware.(string); isName {
+		return parseMiddlewareReference(name)
+	}
+	var name = identityOf(middleware)
+	if standard, isStandard := middleware.(func(http.Handler) http.Handler); isStandard {
+		return &namedMiddleware{MagicalFunc: container.NewMagicalFunc(WrapMiddleware(standard)), name: name, function: functionOf(name)}
+	}
+	if terminable, isTerminable := middleware.(TerminableMiddleware); isTerminable {
+		return &namedMiddleware{MagicalFunc: container.NewMagicalFunc(terminable.Handle), name: name, function: name, terminable: terminable}
+	}
+	return &namedMiddleware{MagicalFunc: container.NewMagicalFunc(middleware), name: name, function: functionOf(name)}
+}
+
+// functionOf 闭包和方法值的函数名不能区分不同的实例，例如 Throttle(10) 和 Throttle(60) 都是 pkg.Throttle.func1，不作为排除和排序的依据
+func functionOf(name string) string {
+	if anonymousFunction.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// identityOf 获取中间件的标识，函数为函数名，其他中间件为类型名
+func identityOf(middleware interface{}) string {
+	if value := reflect.ValueOf(middleware); value.Kind() == reflect.Func {
+		if function := runtime.FuncForPC(value.Pointer()); function != nil {
+			return function.Name()
+		}
+	}
+	return reflect.TypeOf(middleware).String()
+}
+
+// middlewareName 获取中间件的名称，别名中间件为别名，函数中间件为函数名
+func middlewareName(middleware contracts.MagicalFunc) string {
+	switch value := middleware.(type) {
+	case *namedMiddleware:
+		if len(value.params) > 0 {
+			return value.name + ":" + strings.Join(value.params, ",")
+		}
+		return value.name
+	case *middlewareReference:
+		return value.name
+	}
+	return reflect.TypeOf(middleware).String()
+}
+
+// WithoutMiddleware 从路由或者路由组继承的中间件中排除指定的中间件，可以是别名、中间件组名或者中间件函数
+// 闭包和方法值无法按值排除，需要先通过 AliasMiddleware 注册别名再按别名排除
+// 例如 webhook 路由排除 web 组中的 csrf：group.Post("/webhook", handler, http.WithoutMiddleware("csrf"))
+func WithoutMiddleware(middlewares ...interface{}) RouteOption {
+	var excluded = make([]interface{}, 0, len(middlewares))
+	for _, middleware := range middlewares {
+		switch value := middleware.(type) {
+		case string:
+			excluded = append(excluded, parseMiddlewareReference(value).name)
+		case contracts.MagicalFunc:
+			excluded = append(excluded, value)
+		default:
+			var function = functionOf(identityOf(value))
+			if function == "" {
+				panic(exceptions.WithPrevious(AnonymousMiddlewareError, contracts.Fields{"middleware": identityOf(value)}, nil))
+			}
+			excluded = append(excluded, function)
+		}
+	}
+	return func(attributes *attributes) {
+		attributes.excluded = append(attributes.excluded, excluded...)
+	}
+}
+
+// withoutMiddlewares 排除中间件，中间件组会排除组内所有的中间件
+func (this *Router) withoutMiddlewares(middlewares []contracts.MagicalFunc, excluded []interface{}) []contracts.MagicalFunc {
+	if len(excluded) == 0 {
+		return middlewares
+	}
+	var (
+		names = make(map[string]bool)
+		funcs = make(map[contracts.MagicalFunc]bool)
+	)
+	for _, item := range excluded {
+		if name, isName := item.(string); isName {
+			names[name] = true
+			if group, isGroup := this.middlewareGroups[name]; isGroup {
+				for _, middleware := range this.resolveMiddlewares(append([]contracts.MagicalFunc{}, group...)) {
+					if named, isNamed := middleware.(*namedMiddleware); isNamed && !named.anonymous() {
+						names[named.name] = true
+					} else {
+						funcs[middleware] = true
+					}
+				}
+			}
+		} else {
+			funcs[item.(contracts.MagicalFunc)] = true
+		}
+	}
+
+	var result = make([]contracts.MagicalFunc, 0, len(middlewares))
+	for _, middleware := range middlewares {
+		if named, isNamed := middleware.(*namedMiddleware); isNamed && (names[named.name] || names[named.function] || funcs[named.MagicalFunc]) {
+			continue
+		}
+		if !funcs[middleware] {
+			result = append(result, middleware)
+		}
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAliasChain(t *testing.T) {
+	var router = newTestRouter()
+	router.AliasMiddleware("throttle", func(request *Request, next contracts.Pipe, params MiddlewareParams) interface{} {
+		request.Response().Header().Set("X-Throttle", strings.Join(params, ","))
+		return next(request)
+	})
+	router.AliasMiddleware("limit", "throttle:60,1")
+	router.AliasMiddleware("api", "limit")
+	router.Get("/", func() string { return "ok" }, "api")
+
+	var response = serve(router, http.MethodGet, "/")
+	if response.Body.String() != "ok" || response.Header().Get("X-Throttle") != "60,1" {
+		t.Fatalf("unexpected response %q with throttle %q", response.Body.String(), response.Header().Get("X-Throttle"))
+	}
+}
+
+func TestMiddlewareCycle(t *testing.T) {
+	for name, register := range map[string]func(router *Router){
+		"group": func(router *Router) {
+			router.MiddlewareGroup("web", "csrf", "web")
+		},
+		"alias": func(router *Router) {
+			router.AliasMiddleware("web", "session")
+			router.AliasMiddleware("session", "web")
+		},
+	} {
+		var router = newTestRouter()
+		router.AliasMiddleware("csrf", func(request *Request, next contracts.Pipe) interface{} { return next(request) })
+		register(router)
+		router.Get("/", func() string { return "ok" }, "web")
+
+		func() {
+			defer func() {
+				if exception, isException := recover().(contracts.Exception); !isException || !strings.Contains(exception.Error(), "references itself") {
+					t.Errorf("%s: expected a middleware cycle panic when mounting, got %v", name, exception)
+				}
+			}()
+			router.prepare()
+		}()
+	}
+}
+
+// testLimit 同一个工厂返回的闭包中间件，函数名相同
+func testLimit(limit string) func(request *Request, next contracts.Pipe) interface{} {
+	return func(request *Request, next contracts.Pipe) interface{} {
+		request.Response().Header().Add("X-Limit", limit)
+		return next(request)
+	}
+}
+
+func TestWithoutMiddlewareFromSameFactory(t *testing.T) {
+	var router = newTestRouter()
+	router.AliasMiddleware("limit10", testLimit("10"))
+	router.MiddlewareGroup("limits", testLimit("20"))
+	var api = router.Group("/api", "limit10", "limits", testLimit("60"))
+	api.Get("/all", func() string { return "all" })
+	api.Get("/alias", func() string { return "alias" }, WithoutMiddleware("limit10"))
+	api.Get("/group", func() string { return "group" }, WithoutMiddleware("limits"))
+
+	for path, expected := range map[string]string{
+		"/api/all":   "10,20,60",
+		"/api/alias": "20,60",
+		"/api/group": "10,60",
+	} {
+		if limits := strings.Join(serve(router, http.MethodGet, path).Header().Values("X-Limit"), ","); limits != expected {
+			t.Errorf("%s: expected limits %s, got %s", path, expected, limits)
+		}
+	}
+
+	defer func() {
+		if err, isErr := recover().(error); !isErr || err.Error() != AnonymousMiddlewareError.Error() {
+			t.Fatalf("expected closures to be rejected by WithoutMiddleware, got %v", err)
+		}
+	}()
+	WithoutMiddleware(testLimit("10"))
+}
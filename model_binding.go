@@ -0,0 +1,76 @@
+package http
+
+import (
+	"errors"
+	"github.com/goal-web/container"
+	"github.com/goal-web/contracts"
+	"reflect"
+	"strings"
+)
+
+var (
+	ModelResolverError = errors.New("model resolver must return the model") // 模型解析器至少要有一个返回值
+)
+
+// ModelQuery 解析路由模型时的查询条件
+type ModelQuery struct {
+	// Param 路由参数名，例如 :user 中的 user
+	Param string
+
+	// Field 自定义的查询键，例如 :user:slug 中的 slug，为空时由解析器使用默认键
+	Field string
+
+	// Value 路由参数的值
+	Value string
+
+	// Parent 嵌套路由中上一个绑定的模型，例如 /users/:user/posts/:post 解析 post 时为 user 模型，用于限定查询范围
+	Parent interface{}
+}
+
+type modelBinding struct {
+	param     string
+	modelType reflect.Type
+	resolver  contracts.MagicalFunc
+}
+
+// routeParam 路由路径中的参数
+type routeParam struct {
+	raw   string // 路由中完整的参数名，例如 user:slug
+	name  string
+	field string
+}
+
+// Model 把路由参数绑定到模型，resolver 的参数由容器注入，可以接收 ModelQuery 和请求，
+// 返回值的类型即模型的类型，返回 nil 时响应 404，可以额外返回一个 error
+// 例如 router.Model("user", func(query http.ModelQuery, users *UserRepository) *User { ... })
+// 之后处理器可以直接声明 func(user *User) 参数
+func (this *Router) Model(param string, resolver interface{}) {
+	var magicalFunc = container.NewMagicalFunc(resolver)
+	if magicalFunc.NumOut() == 0 {
+		panic(ModelResolverError)
+	}
+	this.bindings[param] = &modelBinding{
+		param:     param,
+		modelType: magicalFunc.Returns()[0],
+		resolver:  magicalFunc,
+	}
+}
+
+// parseRouteParams 解析路由路径中的参数
+func parseRouteParams(path string) []routeParam {
+	var params = make([]routeParam, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		var (
+			raw   = segment[1:]
+			param = routeParam{raw: raw, name: raw}
+		)
+		if index := strings.Index(raw, ":"); index > 0 {
+			param.name, param.field = raw[:index], raw[index+1:]
+		}
+		params = append(params, param)
+	}
+	return params
+}
@@ -0,0 +1,384 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/labstack/echo/v4"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// mountedRoute 装配时的路由，包含所属路由组的中间件和约束
+type mountedRoute struct {
+	route       contracts.Route
+	path        string
+	handler     contracts.MagicalFunc
+	middlewares []contracts.MagicalFunc // 展开后的完整中间件链，包括全局中间件
+	pipe        contracts.Pipe          // 装配时组装好的中间件链和处理器
+	terminators []TerminableMiddleware
+	constraints map[string]*regexp.Regexp
+	paramNames  []string
+	domain      *domainPattern
+	name        string
+	version     string
+	negotiated  bool // 版本由请求协商，而不是由 url 中的版本前缀决定
+	deprecation *deprecation
+	listeners   []string
+}
+
+// matches 判断请求的域名、版本和路由参数是否满足约束，域名中的参数会追加到路由参数中
+func (this *mountedRoute) matches(context echo.Context, version string) bool {
+	if this.negotiated && this.version != version {
+		return false
+	}
+	if len(this.listeners) > 0 && !contains(this.listeners, ListenerOf(context.Request())) {
+		return false
+	}
+	if this.domain != nil {
+		var hostValues, matched = this.domain.match(context.Request().Host)
+		if !matched {
+			return false
+		}
+		var values = append(append(make([]string, 0, len(this.paramNames)+len(hostValues)), context.ParamValues()...), hostValues...)
+		context.SetParamNames(append(append(make([]string, 0, len(values)), this.paramNames...), this.domain.params...)...)
+		context.SetParamValues(values...)
+	}
+	for param, pattern := range this.constraints {
+		if !pattern.MatchString(context.Param(param)) {
+			return false
+		}
+	}
+	return true
+}
+
+// mount 装配所有路由，方法和路径相同（参数名可以不同）的路由按注册顺序依次匹配约束
+func (this *Router) mount() {
+	var (
+		keys       = make([]string, 0)
+		candidates = make(map[string][]*mountedRoute)
+		register   = func(mounted *mountedRoute) {
+			this.mounted = append(this.mounted, mounted)
+			for _, method := range mounted.route.Method() {
+				var key = method + " " + normalizePath(mounted.path)
+				if _, exists := candidates[key]; !exists {
+					keys = append(keys, key)
+				}
+				candidates[key] = append(candidates[key], mounted)
+			}
+		}
+		collect func(routes []contracts.Route, middlewares []contracts.MagicalFunc, groupAttributes *attributes)
+	)
+
+	this.named = make(map[string]*mountedRoute)
+	this.routeVersions = make(map[string][]string)
+	this.fallbacks = make([]*mountedRoute, 0)
+	this.mounted = make([]*mountedRoute, 0)
+	collect = func(routes []contracts.Route, middlewares []contracts.MagicalFunc, groupAttributes *attributes) {
+		for _, routeInstance := range routes {
+			var mounted = this.compileRoute(routeInstance, middlewares, groupAttributes)
+			if mounted.name != "" {
+				this.named[mounted.name] = mounted
+			}
+
+			if groupAttributes != nil && groupAttributes.version != nil {
+				// 版本路由同时注册协商版本的路径和带版本前缀的路径
+				var (
+					version  = groupAttributes.version
+					explicit = *mounted
+				)
+				explicit.version = version.name
+				explicit.path = version.prefix + "/" + version.name + strings.TrimPrefix(mounted.path, version.prefix)
+				mounted.version, mounted.negotiated = version.name, true
+				register(&explicit)
+				for _, method := range routeInstance.Method() {
+					var key = method + " " + mounted.path
+					this.routeVersions[key] = append(this.routeVersions[key], version.name)
+				}
+			}
+			register(mounted)
+		}
+	}
+
+	collect(this.routes, nil, nil)
+	for _, routeGroup := range this.groups {
+		this.collectGroup(routeGroup, nil, nil, collect)
+	}
+
+	if this.fallback != nil {
+		this.fallbacks = append(this.fallbacks, this.compileRoute(this.fallback, nil, nil))
+	}
+	// 前缀越长的兜底路由越优先，限定了域名的兜底路由优先
+	sort.SliceStable(this.fallbacks, func(i, j int) bool {
+		if len(this.fallbacks[i].path) != len(this.fallbacks[j].path) {
+			return len(this.fallbacks[i].path) > len(this.fallbacks[j].path)
+		}
+		return this.fallbacks[i].domain != nil && this.fallbacks[j].domain == nil
+	})
+
+	// 域名参数在匹配时追加到路由参数中，需要预留参数空间
+	var maxParam = 1
+	for _, mounted := range append(append([]*mountedRoute{}, this.mounted...), this.fallbacks...) {
+		var count = len(mounted.paramNames) + 1
+		if mounted.domain != nil {
+			count += len(mounted.domain.params)
+		}
+		if count > maxParam {
+			maxParam = count
+		}
+	}
+	this.engine.SetMaxParam(maxParam)
+
+	var paths = make(map[string]string)
+	this.allowed = make(map[string][]string)
+	for _, key := range keys {
+		var (
+			mounted = candidates[key]
+			method  = key[:strings.Index(key, " ")]
+			path    = key[len(method)+1:]
+		)
+		// 限定了域名的路由优先匹配
+		sort.SliceStable(mounted, func(i, j int) bool {
+			return mounted[i].domain != nil && mounted[j].domain == nil
+		})
+		this.engine.Add(method, mounted[0].path, this.dispatch(mounted))
+
+		if _, exists := paths[path]; !exists {
+			paths[path] = mounted[0].path
+		}
+		this.allowed[path] = append(this.allowed[path], method)
+	}
+
+	// GET 路由自动支持 HEAD，所有路径自动响应 OPTIONS
+	for _, key := range keys {
+		var path = strings.TrimPrefix(key, echo.GET+" ")
+		if path != key && !contains(this.allowed[path], echo.HEAD) {
+			this.engine.Add(echo.HEAD, paths[path], this.dispatch(candidates[key]))
+			this.allowed[path] = append(this.allowed[path], echo.HEAD)
+		}
+	}
+	// Mount 之类的通配符路由自己响应 OPTIONS，前缀下的路径不自动响应
+	var wildcards = make([]string, 0)
+	for _, key := range keys {
+		if strings.HasPrefix(key, echo.OPTIONS+" ") && strings.HasSuffix(key, "/*") {
+			wildcards = append(wildcards, strings.TrimSuffix(strings.TrimPrefix(key, echo.OPTIONS+" "), "*"))
+		}
+	}
+	for _, key := range keys {
+		var path = key[strings.Index(key, " ")+1:]
+		if !contains(this.allowed[path], echo.OPTIONS) && !hasAnyPrefix(path, wildcards) {
+			this.allowed[path] = append(this.allowed[path], echo.OPTIONS)
+			this.engine.Add(echo.OPTIONS, paths[path], this.dispatch([]*mountedRoute{this.options(paths[path], path)}))
+		}
+	}
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileRoute 编译路由，合并所属路由组的中间件、约束、域名等属性
+func (this *Router) compileRoute(routeInstance contracts.Route, middlewares []contracts.MagicalFunc, groupAttributes *attributes) *mountedRoute {
+	var pipes = append([]contracts.MagicalFunc{}, middlewares...)
+	if item, isRoute := routeInstance.(*route); isRoute && item.action != "" {
+		var controllerMiddlewares []contracts.MagicalFunc
+		item.handler, controllerMiddlewares = this.resolveAction(item.action)
+		pipes = append(pipes, controllerMiddlewares...)
+	}
+	var mounted = &mountedRoute{
+		route:       routeInstance,
+		path:        routeInstance.Path(),
+		handler:     this.compileHandler(routeInstance),
+		middlewares: append(pipes, routeInstance.Middlewares()...),
+		paramNames:  paramNames(routeInstance.Path()),
+	}
+	if attrs := attributesOf(routeInstance); attrs != nil {
+		mounted.domain, mounted.name, mounted.deprecation, mounted.listeners = attrs.domain, attrs.name, attrs.deprecation, attrs.listeners
+	}
+	if groupAttributes != nil {
+		if mounted.name != "" {
+			mounted.name = groupAttributes.name + mounted.name
+		}
+		if mounted.domain == nil {
+			mounted.domain = groupAttributes.domain
+		}
+		if mounted.deprecation == nil {
+			mounted.deprecation = groupAttributes.deprecation
+		}
+		if len(mounted.listeners) == 0 {
+			mounted.listeners = groupAttributes.listeners
+		}
+	}
+	mounted.constraints = paramConstraints(mergeConstraints(groupAttributes, attributesOf(routeInstance)), mounted)
+	var excluded []interface{}
+	for _, attrs := range []*attributes{groupAttributes, attributesOf(routeInstance)} {
+		if attrs != nil {
+			excluded = append(excluded, attrs.excluded...)
+		}
+	}
+	mounted.middlewares = this.withoutMiddlewares(this.resolveMiddlewares(append(append(
+		make([]contracts.MagicalFunc, 0, len(this.middlewares)+len(mounted.middlewares)), this.middlewares...), mounted.middlewares...,
+	)), excluded)
+	mounted.pipe = this.compilePipe(mounted.handler, mounted.middlewares)
+	mounted.terminators = terminatorsOf(mounted.middlewares)
+	return mounted
+}
+
+// compilePipe 把中间件和处理器组装成调用链，请求时直接调用，不再为每个请求构建 pipeline
+// 只依赖请求、下一个中间件和中间件参数的中间件和处理器不经过容器解析参数，每个请求仍然需要少量分配，例如请求对象和反射调用的参数
+func (this *Router) compilePipe(handler contracts.MagicalFunc, middlewares []contracts.MagicalFunc) contracts.Pipe {
+	var (
+		handlerCall                = compileCall(handler, false)
+		pipe        contracts.Pipe = func(passable interface{}) interface{} {
+			return this.call(handlerCall, passable, nil)
+		}
+	)
+	for index := len(middlewares) - 1; index >= 0; index-- {
+		var middlewareCall, next = compileCall(middlewares[index], true), pipe
+		pipe = func(passable interface{}) interface{} {
+			return this.call(middlewareCall, passable, next)
+		}
+	}
+	return pipe
+}
+
+// collectGroup 收集路由组及其子组的路由，子组继承父组的中间件和属性
+func (this *Router) collectGroup(
+	routeGroup contracts.RouteGroup, parentMiddlewares []contracts.MagicalFunc, parentAttributes *attributes,
+	collect func(routes []contracts.Route, middlewares []contracts.MagicalFunc, groupAttributes *attributes),
+) {
+	var (
+		own         = attributesOf(routeGroup)
+		attrs       = inheritAttributes(parentAttributes, own)
+		middlewares = routeGroup.Middlewares()
+	)
+	if own == nil || !own.isolated {
+		middlewares = append(append(make([]contracts.MagicalFunc, 0, len(parentMiddlewares)+len(middlewares)), parentMiddlewares...), middlewares...)
+	}
+
+	collect(routeGroup.Routes(), middlewares, attrs)
+	if item, isGroup := routeGroup.(*group); isGroup && item.fallback != nil {
+		this.fallbacks = append(this.fallbacks, this.compileRoute(item.fallback, middlewares, attrs))
+	}
+
+	for _, child := range routeGroup.Groups() {
+		this.collectGroup(child, middlewares, attrs, collect)
+	}
+}
+
+// dispatch 选出第一个满足约束的路由并处理请求，都不满足时响应 404
+func (this *Router) dispatch(candidates []*mountedRoute) echo.HandlerFunc {
+	var versioned bool
+	for _, mounted := range candidates {
+		versioned = versioned || mounted.negotiated
+	}
+	return func(context echo.Context) error {
+		var version string
+		if versioned {
+			version = this.negotiateVersion(context)
+		}
+		for _, mounted := range candidates {
+			if len(candidates) > 1 || mounted.domain != nil {
+				context.SetParamNames(mounted.paramNames...)
+			}
+			if mounted.matches(context, version) {
+				this.handle(mounted, context)
+				return nil
+			}
+		}
+		return echo.ErrNotFound
+	}
+}
+
+// handle 通过中间件和处理器处理请求
+func (this *Router) handle(mounted *mountedRoute, context echo.Context) {
+	request := this.newRequest(context)
+	if mounted.version != "" {
+		request.Set(versionKey, mounted.version)
+	}
+	if mounted.negotiated {
+		context.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept+", "+this.versioning.Header)
+	}
+	if mounted.deprecation != nil {
+		writeDeprecation(context.Response().Header(), mounted.deprecation)
+	}
+	if len(mounted.terminators) > 0 {
+		context.Response().Writer = newBufferedWriter(context.Response().Writer)
+	}
+	defer func() {
+		defer this.terminate(mounted.terminators, request, context.Response())
+		this.events.Dispatch(&RequestAfter{request})
+	}()
+
+	// 触发钩子
+	this.events.Dispatch(&RequestBefore{request})
+
+	var result = mounted.pipe(request)
+
+	this.events.Dispatch(&ResponseBefore{request})
+
+	HandleResponse(result, request)
+}
+
+// mergeConstraints 合并路由组和路由的约束，路由的约束优先
+func mergeConstraints(groupAttributes, routeAttributes *attributes) map[string]*regexp.Regexp {
+	var merged = make(map[string]*regexp.Regexp)
+	for _, attrs := range []*attributes{groupAttributes, routeAttributes} {
+		if attrs != nil {
+			for param, pattern := range attrs.constraints {
+				merged[param] = pattern
+			}
+		}
+	}
+	return merged
+}
+
+// normalizePath 去掉路径中的参数名，echo 中只有参数名不同的路径是同一个路由
+func normalizePath(path string) string {
+	var segments = strings.Split(path, "/")
+	for index, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[index] = ":"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// paramNames 路由在引擎中的参数名，和引擎注册路由时一致，:user:slug 的参数名为 user:slug，通配符的参数名为 *
+func paramNames(path string) []string {
+	var names = make([]string, 0)
+	for _, segment := range strings.Split(path, "/") {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			names = append(names, segment[1:])
+		case strings.HasSuffix(segment, "*"):
+			names = append(names, "*")
+		}
+	}
+	return names
+}
+
+// paramConstraints 把按参数名声明的约束转换成按引擎中的参数名查找，例如 Where("user", ...) 约束 :user:slug
+// 路由组的约束只作用于路由中存在的参数，路径和域名中都没有的参数不受约束
+func paramConstraints(constraints map[string]*regexp.Regexp, mounted *mountedRoute) map[string]*regexp.Regexp {
+	var result = make(map[string]*regexp.Regexp, len(constraints))
+	for _, param := range parseRouteParams(mounted.path) {
+		if pattern, exists := constraints[param.name]; exists {
+			result[param.raw] = pattern
+		}
+	}
+	var names = mounted.paramNames
+	if mounted.domain != nil {
+		names = append(append([]string{}, names...), mounted.domain.params...)
+	}
+	for _, name := range names {
+		if pattern, exists := constraints[name]; exists {
+			result[name] = pattern
+		}
+	}
+	return result
+}
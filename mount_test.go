@@ -0,0 +1,146 @@
+package http
+
+import (
+	"fmt"
+	"github.com/goal-web/container"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/pipeline"
+	"github.com/goal-web/supports/utils"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const benchmarkRoutes = 5000
+
+func benchmarkMiddleware(request contracts.HttpRequest, next contracts.Pipe) interface{} {
+	return next(request)
+}
+
+func benchmarkHandler(request contracts.HttpRequest) string {
+	return request.Param("id")
+}
+
+// BenchmarkDispatch 装配时预编译中间件链后的请求分发
+func BenchmarkDispatch(b *testing.B) {
+	var router = newTestRouter()
+	router.Use(benchmarkMiddleware)
+	for i := 0; i < benchmarkRoutes; i++ {
+		router.Group(fmt.Sprintf("/group%d", i), benchmarkMiddleware).
+			Get("/users/:id", benchmarkHandler, benchmarkMiddleware)
+	}
+	router.prepare()
+
+	benchmarkServe(b, router.engine)
+}
+
+// BenchmarkDispatchPipeline 每个请求都拼接中间件并构建 pipeline 的分发方式，作为对比
+func BenchmarkDispatchPipeline(b *testing.B) {
+	var (
+		router      = newTestRouter()
+		engine      = echo.New()
+		middlewares = []contracts.MagicalFunc{container.NewMagicalFunc(benchmarkMiddleware)}
+		handler     = container.NewMagicalFunc(benchmarkHandler)
+	)
+	for i := 0; i < benchmarkRoutes; i++ {
+		var (
+			groupMiddlewares = []contracts.MagicalFunc{container.NewMagicalFunc(benchmarkMiddleware)}
+			routeMiddlewares = []contracts.MagicalFunc{container.NewMagicalFunc(benchmarkMiddleware)}
+		)
+		engine.GET(fmt.Sprintf("/group%d/users/:id", i), func(context echo.Context) error {
+			var request = NewRequest(context)
+			var pipes = append(append(middlewares, groupMiddlewares...), routeMiddlewares...)
+			HandleResponse(pipeline.Static(router.app).SendStatic(request).ThroughStatic(pipes...).ThenStatic(handler), request)
+			return nil
+		})
+	}
+
+	benchmarkServe(b, engine)
+}
+
+// benchmarkServe 请求最后一条路由，路由表越大越能体现分发的开销
+func benchmarkServe(b *testing.B, handler http.Handler) {
+	var request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/group%d/users/1", benchmarkRoutes-1), nil)
+	if body := serveRecorder(handler, request).Body.String(); body != "1" {
+		b.Fatalf("unexpected response %q", body)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}
+}
+
+// dispatchAllocations 分发请求时每个请求的内存分配上限，包括请求对象、反射调用的参数和响应
+// 预编译的分发路径不是零分配的，这里限制分配次数不随路由数量增长，也不会退回到每个请求构建 pipeline
+const dispatchAllocations = 30
+
+// discardWriter 不记录响应的 ResponseWriter，避免统计 httptest.ResponseRecorder 的分配
+type discardWriter struct {
+	header http.Header
+}
+
+func (writer *discardWriter) Header() http.Header            { return writer.header }
+func (writer *discardWriter) Write(data []byte) (int, error) { return len(data), nil }
+func (writer *discardWriter) WriteHeader(int)                {}
+
+func TestDispatchAllocations(t *testing.T) {
+	var allocations = make([]float64, 0, 2)
+	for _, routes := range []int{1, benchmarkRoutes} {
+		var router = newTestRouter()
+		router.Use(benchmarkMiddleware)
+		for i := 0; i < routes; i++ {
+			router.Group(fmt.Sprintf("/group%d", i), benchmarkMiddleware).
+				Get("/users/:id", benchmarkHandler, benchmarkMiddleware)
+		}
+		router.prepare()
+
+		var (
+			request = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/group%d/users/1", routes-1), nil)
+			writer  = &discardWriter{header: http.Header{}}
+		)
+		allocations = append(allocations, testing.AllocsPerRun(100, func() {
+			router.ServeHTTP(writer, request)
+		}))
+	}
+
+	// -race 下 sync.Pool 会随机丢弃对象，echo 的 context 偶尔需要重新分配，允许少量误差
+	if allocations[1] > allocations[0]+2 {
+		t.Errorf("allocations grow with the route table: %v allocs with 1 route, %v with %d", allocations[0], allocations[1], benchmarkRoutes)
+	}
+	if allocations[1] > dispatchAllocations {
+		t.Errorf("dispatch allocates %v times per request, budget is %d", allocations[1], dispatchAllocations)
+	}
+}
+
+func serveRecorder(handler http.Handler, request *http.Request) *httptest.ResponseRecorder {
+	var recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+type greeting struct {
+	text string
+}
+
+// TestDispatchArguments 中间件参数和请求直接注入，其他参数仍然由容器注入
+func TestDispatchArguments(t *testing.T) {
+	var router = newTestRouter()
+	router.app.Instance(utils.GetTypeKey(reflect.TypeOf(greeting{})), greeting{text: "hello"})
+	router.AliasMiddleware("tag", func(request *Request, next contracts.Pipe, params MiddlewareParams) interface{} {
+		request.Response().Header().Set("X-Tag", strings.Join(params, ","))
+		return next(request)
+	})
+	router.Get("/greet/:name", func(request contracts.HttpRequest, greeting greeting) string {
+		return greeting.text + " " + request.Param("name")
+	}, "tag:a,b")
+
+	var response = serve(router, http.MethodGet, "/greet/goal")
+	if response.Body.String() != "hello goal" || response.Header().Get("X-Tag") != "a,b" {
+		t.Fatalf("unexpected response %q with tag %q", response.Body.String(), response.Header().Get("X-Tag"))
+	}
+}
@@ -0,0 +1,201 @@
+package http
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	InvalidProxyError = errors.New("trusted proxy must be an ip, a cidr or one of loopback, private, linklocal, *")
+
+	proxyKeywords = map[string][]string{
+		"loopback":  {"127.0.0.0/8", "::1/128"},
+		"private":   {"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"},
+		"linklocal": {"169.254.0.0/16", "fe80::/10"},
+		"*":         {"0.0.0.0/0", "::/0"},
+	}
+
+	// defaultProxies 没有配置时只信任回环地址，同一内网中的其他机器可以伪造请求头，内网的代理需要在 TrustedProxies 中配置
+	defaultProxies = newTrustedProxies([]string{"loopback"})
+)
+
+// trustedProxies 可信代理，只有来自可信代理的 Forwarded、X-Forwarded-* 和 X-Real-IP 请求头才会被采用
+type trustedProxies struct {
+	networks []*net.IPNet
+}
+
+// newTrustedProxies 解析可信代理，可以是 ip、cidr 或者 loopback、private、linklocal、* 关键字
+func newTrustedProxies(items []string) *trustedProxies {
+	var proxies = &trustedProxies{}
+	for _, item := range items {
+		var cidrs, isKeyword = proxyKeywords[item]
+		if !isKeyword {
+			cidrs = []string{item}
+		}
+		for _, cidr := range cidrs {
+			if !strings.Contains(cidr, "/") {
+				if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+					cidr += "/32"
+				} else {
+					cidr += "/128"
+				}
+			}
+			var _, network, err = net.ParseCIDR(cidr)
+			if err != nil {
+				panic(InvalidProxyError)
+			}
+			proxies.networks = append(proxies.networks, network)
+		}
+	}
+	return proxies
+}
+
+func (this *trustedProxies) trusts(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, network := range this.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// newRequest 创建使用路由器可信代理配置的请求
+func (this *Router) newRequest(ctx echo.Context) *Request {
+	var request = NewRequest(ctx).(*Request)
+	request.proxies = this.proxies
+	return request
+}
+
+// origin 客户端的原始信息
+type origin struct {
+	ip     string
+	scheme string
+	host   string
+	port   int
+}
+
+// forwardedHop Forwarded 或者 X-Forwarded-For 中的一跳
+type forwardedHop struct {
+	ip    string
+	proto string
+	host  string
+}
+
+// resolve 解析客户端的原始信息，请求头只在连接来自可信代理时采用，代理链从右往左找到第一个不可信的地址作为客户端地址
+func (this *trustedProxies) resolve(request *http.Request) origin {
+	var result = origin{ip: stripPort(request.RemoteAddr), scheme: "http", host: request.Host}
+	if request.TLS != nil {
+		result.scheme = "https"
+	}
+
+	if this.trusts(net.ParseIP(result.ip)) {
+		var hops, standard = forwardedHops(request.Header)
+		for index := len(hops) - 1; index >= 0; index-- {
+			if net.ParseIP(hops[index].ip) == nil {
+				break
+			}
+			result.ip = hops[index].ip
+			if hops[index].proto != "" {
+				result.scheme = hops[index].proto
+			}
+			if hops[index].host != "" {
+				result.host = hops[index].host
+			}
+			if !this.trusts(net.ParseIP(hops[index].ip)) {
+				break
+			}
+		}
+		if len(hops) == 0 {
+			if realIP := strings.TrimSpace(request.Header.Get(echo.HeaderXRealIP)); net.ParseIP(realIP) != nil {
+				result.ip = realIP
+			}
+		}
+		if standard {
+			return result.withPort()
+		}
+		if proto := lastValue(request.Header.Get("X-Forwarded-Proto")); proto != "" {
+			result.scheme = strings.ToLower(proto)
+		}
+		if host := lastValue(request.Header.Get("X-Forwarded-Host")); host != "" {
+			result.host = host
+		}
+		if port, err := strconv.Atoi(lastValue(request.Header.Get("X-Forwarded-Port"))); err == nil {
+			result.port = port
+		}
+	}
+
+	return result.withPort()
+}
+
+// withPort 没有转发端口时从 host 中取端口，host 中也没有时使用协议的默认端口
+func (this origin) withPort() origin {
+	if this.port != 0 {
+		return this
+	}
+	if _, port, err := net.SplitHostPort(this.host); err == nil {
+		this.port, _ = strconv.Atoi(port)
+	} else if this.scheme == "https" {
+		this.port = 443
+	} else {
+		this.port = 80
+	}
+	return this
+}
+
+// forwardedHops 解析代理链，优先使用 Forwarded，没有时使用 X-Forwarded-For，第二个返回值表示是否来自 Forwarded
+func forwardedHops(header http.Header) ([]forwardedHop, bool) {
+	var hops []forwardedHop
+	for _, value := range header.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			var hop forwardedHop
+			for _, pair := range strings.Split(element, ";") {
+				var key, val, found = strings.Cut(strings.TrimSpace(pair), "=")
+				if !found {
+					continue
+				}
+				val = strings.Trim(val, `"`)
+				switch strings.ToLower(key) {
+				case "for":
+					hop.ip = stripPort(val)
+				case "proto":
+					hop.proto = strings.ToLower(val)
+				case "host":
+					hop.host = val
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	if len(hops) > 0 {
+		return hops, true
+	}
+
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, ip := range strings.Split(value, ",") {
+			hops = append(hops, forwardedHop{ip: stripPort(strings.TrimSpace(ip))})
+		}
+	}
+	return hops, false
+}
+
+// stripPort 去掉地址中的端口和 ipv6 的方括号
+func stripPort(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+}
+
+func lastValue(value string) string {
+	if index := strings.LastIndex(value, ","); index >= 0 {
+		value = value[index+1:]
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	var cases = []struct {
+		proxies    *trustedProxies
+		remoteAddr string
+		expected   string
+	}{
+		{defaultProxies, "127.0.0.1:1234", "203.0.113.7"},
+		{defaultProxies, "[::1]:1234", "203.0.113.7"},
+		{defaultProxies, "10.0.0.2:1234", "10.0.0.2"},
+		{defaultProxies, "192.168.1.2:1234", "192.168.1.2"},
+		{newTrustedProxies([]string{"private"}), "10.0.0.2:1234", "203.0.113.7"},
+		{newTrustedProxies([]string{"10.0.0.2"}), "10.0.0.3:1234", "10.0.0.3"},
+	}
+
+	for _, item := range cases {
+		var request = httptest.NewRequest("GET", "/", nil)
+		request.RemoteAddr = item.remoteAddr
+		request.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+		if ip := item.proxies.resolve(request).ip; ip != item.expected {
+			t.Errorf("remote %s: expected %s, got %s", item.remoteAddr, item.expected, ip)
+		}
+	}
+}
+
+func TestResolveOrigin(t *testing.T) {
+	var internal = newTrustedProxies([]string{"10.0.0.0/8"})
+	var cases = []struct {
+		name       string
+		proxies    *trustedProxies
+		remoteAddr string
+		headers    map[string]string
+		expected   origin
+	}{
+		{"forwarded is preferred", defaultProxies, "127.0.0.1:1234", map[string]string{
+			"Forwarded":         `for=198.51.100.1;proto=https;host=example.org`,
+			"X-Forwarded-For":   "203.0.113.7",
+			"X-Forwarded-Proto": "http",
+			"X-Forwarded-Host":  "spoofed.example.com",
+		}, origin{"198.51.100.1", "https", "example.org", 443}},
+		{"forwarded ipv6", defaultProxies, "[::1]:1234", map[string]string{
+			"Forwarded": `for="[2001:db8::1]:4711";proto=https`,
+		}, origin{"2001:db8::1", "https", "example.com", 443}},
+		{"x-forwarded headers", defaultProxies, "127.0.0.1:1234", map[string]string{
+			"X-Forwarded-For":   "203.0.113.7",
+			"X-Forwarded-Proto": "http, HTTPS",
+			"X-Forwarded-Host":  "example.org",
+			"X-Forwarded-Port":  "8443",
+		}, origin{"203.0.113.7", "https", "example.org", 8443}},
+		{"port from forwarded host", defaultProxies, "127.0.0.1:1234", map[string]string{
+			"X-Forwarded-Host": "example.org:8080",
+		}, origin{"127.0.0.1", "http", "example.org:8080", 8080}},
+		{"real ip without chain", defaultProxies, "127.0.0.1:1234", map[string]string{
+			"X-Real-IP": "203.0.113.8",
+		}, origin{"203.0.113.8", "http", "example.com", 80}},
+		{"trusted chain", internal, "10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For": "203.0.113.7, 10.0.0.3, 10.0.0.2",
+		}, origin{"203.0.113.7", "http", "example.com", 80}},
+		{"untrusted hop mid chain", internal, "10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For": "203.0.113.7, 198.51.100.9, 10.0.0.2",
+		}, origin{"198.51.100.9", "http", "example.com", 80}},
+		{"invalid hop stops the chain", internal, "10.0.0.1:1234", map[string]string{
+			"X-Forwarded-For": "203.0.113.7, unknown, 10.0.0.2",
+		}, origin{"10.0.0.2", "http", "example.com", 80}},
+		{"spoofed by untrusted peer", defaultProxies, "198.51.100.9:1234", map[string]string{
+			"Forwarded":         `for=203.0.113.1;proto=https;host=evil.example`,
+			"X-Forwarded-For":   "203.0.113.7",
+			"X-Forwarded-Proto": "https",
+			"X-Forwarded-Host":  "evil.example",
+			"X-Forwarded-Port":  "8443",
+			"X-Real-IP":         "203.0.113.8",
+		}, origin{"198.51.100.9", "http", "example.com", 80}},
+	}
+
+	for _, item := range cases {
+		var request = httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = item.remoteAddr
+		for key, value := range item.headers {
+			request.Header.Set(key, value)
+		}
+
+		if result := item.proxies.resolve(request); result != item.expected {
+			t.Errorf("%s: expected %+v, got %+v", item.name, item.expected, result)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// redirectMethods 重定向路由响应的方法，HEAD 和 OPTIONS 在装配时自动添加
+var redirectMethods = []string{echo.GET, echo.POST, echo.PUT, echo.PATCH, echo.DELETE}
+
+// redirectTo 生成重定向处理器，to 中的 :param 和 * 会替换成请求中转义后的同名参数，并保留请求的查询字符串
+// to 是站内地址时，替换参数后指向其他站点（例如 //evil.com）的重定向会被拒绝
+func redirectTo(to string, status int) func(request contracts.HttpRequest) interface{} {
+	var query string
+	if index := strings.Index(to, "?"); index >= 0 {
+		to, query = to[:index], to[index:]
+	}
+	var (
+		segments = strings.Split(to, "/")
+		external = isExternalUrl(to)
+	)
+	return func(request contracts.HttpRequest) interface{} {
+		var target = make([]string, len(segments))
+		for index, segment := range segments {
+			switch {
+			case strings.HasPrefix(segment, ":"):
+				target[index] = url.PathEscape(unescapeParam(request.Param(segment[1:])))
+			case segment == "*":
+				target[index] = escapeSegments(unescapeParam(request.Param("*")))
+			default:
+				target[index] = segment
+			}
+		}
+
+		var location = strings.Join(target, "/") + query
+		if !external && isExternalUrl(location) {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid redirect target")
+		}
+		if raw := request.Request().URL.RawQuery; raw != "" {
+			if query != "" {
+				location += "&" + raw
+			} else {
+				location += "?" + raw
+			}
+		}
+
+		return RedirectResponse(location, status)
+	}
+}
+
+// unescapeParam 路由参数可能是转义过的原始路径，先还原再转义，避免重复转义
+func unescapeParam(param string) string {
+	if value, err := url.PathUnescape(param); err == nil {
+		return value
+	}
+	return param
+}
+
+// escapeSegments 逐段转义通配符参数，保留其中的 /
+func escapeSegments(path string) string {
+	var segments = strings.Split(path, "/")
+	for index, segment := range segments {
+		segments[index] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// isExternalUrl 判断地址是否指向其他站点，包括带协议的地址和 //host 形式的地址，浏览器会把 \ 当作 / 处理
+func isExternalUrl(location string) bool {
+	if strings.HasPrefix(location, "//") || strings.HasPrefix(location, "/\\") {
+		return true
+	}
+	var parsed, err = url.Parse(location)
+	return err != nil || parsed.Scheme != "" || parsed.Host != ""
+}
+
+// viewOf 生成渲染模板的处理器
+func viewOf(template string, data []contracts.Fields) func() contracts.HttpResponse {
+	var fields = contracts.Fields{}
+	for _, item := range data {
+		for key, value := range item {
+			fields[key] = value
+		}
+	}
+	return func() contracts.HttpResponse {
+		return ViewResponse(template, fields)
+	}
+}
+
+func redirectStatus(status []int) int {
+	if len(status) > 0 {
+		return status[0]
+	}
+	return http.StatusFound
+}
+
+// Redirect 注册重定向路由，默认状态码为 302，例如 router.Redirect("/old/:id", "/new/:id")
+func (this *Router) Redirect(from, to string, status ...int) {
+	this.Add(redirectMethods, from, redirectTo(to, redirectStatus(status)))
+}
+
+// PermanentRedirect 注册 301 重定向路由
+func (this *Router) PermanentRedirect(from, to string) {
+	this.Redirect(from, to, http.StatusMovedPermanently)
+}
+
+// Redirects 批量注册重定向路由，键为旧地址，值为新地址，适合迁移大量旧地址
+func (this *Router) Redirects(redirects map[string]string, status ...int) {
+	for from, to := range redirects {
+		this.Redirect(from, to, status...)
+	}
+}
+
+// View 注册只渲染模板的路由，需要先通过 SetRenderer 设置渲染器
+func (this *Router) View(path, template string, data ...contracts.Fields) {
+	this.Get(path, viewOf(template, data))
+}
+
+// SetRenderer 设置模板渲染器
+func (this *Router) SetRenderer(renderer echo.Renderer) {
+	this.engine.SetRenderer(renderer)
+}
+
+// Redirect 在路由组中注册重定向路由，参考 Router.Redirect
+func (group *group) Redirect(from, to string, status ...int) contracts.RouteGroup {
+	return group.Add(redirectMethods, from, redirectTo(to, redirectStatus(status)))
+}
+
+// PermanentRedirect 在路由组中注册 301 重定向路由
+func (group *group) PermanentRedirect(from, to string) contracts.RouteGroup {
+	return group.Redirect(from, to, http.StatusMovedPermanently)
+}
+
+// View 在路由组中注册只渲染模板的路由
+func (group *group) View(path, template string, data ...contracts.Fields) contracts.RouteGroup {
+	return group.Get(path, viewOf(template, data))
+}
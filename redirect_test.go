@@ -0,0 +1,59 @@
+package http
+
+import (
+	"fmt"
+	"github.com/goal-web/contracts"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// testRenderer 把模板名和数据原样输出
+type testRenderer struct{}
+
+func (testRenderer) Render(writer io.Writer, name string, data interface{}, _ echo.Context) error {
+	_, err := fmt.Fprint(writer, name, " ", data)
+	return err
+}
+
+func TestRedirects(t *testing.T) {
+	var router = newTestRouter()
+	router.Redirect("/old/:id", "/new/:id")
+	router.PermanentRedirect("/docs/*", "https://docs.example.com/v2/*?lang=en")
+	router.Redirect("/files/*", "/*", http.StatusTemporaryRedirect)
+	router.Group("/legacy").(*group).Redirect("/users/:id", "/users/:id")
+
+	for _, item := range []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/old/5?page=2", http.StatusFound, "/new/5?page=2"},
+		{"/old/a%20b", http.StatusFound, "/new/a%20b"},
+		{"/old/a%2Fb", http.StatusFound, "/new/a%2Fb"},
+		{"/docs/guide/intro?v=1", http.StatusMovedPermanently, "https://docs.example.com/v2/guide/intro?lang=en&v=1"},
+		{"/files/a/b", http.StatusTemporaryRedirect, "/a/b"},
+		{"/legacy/users/3", http.StatusFound, "/users/3"},
+		{"/files//evil.com", http.StatusBadRequest, ""},
+		{"/files/%2F%2Fevil.com", http.StatusBadRequest, ""},
+	} {
+		var response = serve(router, http.MethodGet, item.path)
+		if response.Code != item.code || response.Header().Get("Location") != item.location {
+			t.Errorf("%s: expected %d %q, got %d %q", item.path, item.code, item.location, response.Code, response.Header().Get("Location"))
+		}
+	}
+	if response := serve(router, http.MethodPost, "/old/5"); response.Code != http.StatusFound {
+		t.Errorf("redirects must answer every method, got %d for POST", response.Code)
+	}
+}
+
+func TestView(t *testing.T) {
+	var router = newTestRouter()
+	router.SetRenderer(testRenderer{})
+	router.View("/about", "about.html", contracts.Fields{"title": "About"})
+
+	if body := serve(router, http.MethodGet, "/about").Body.String(); body != "about.html map[title:About]" {
+		t.Fatalf("unexpected view %q", body)
+	}
+}
@@ -12,7 +12,9 @@ import (
 type Request struct {
 	supports.BaseFields
 	echo.Context
-	fields contracts.Fields
+	fields  contracts.Fields
+	proxies *trustedProxies
+	origin  *origin
 }
 
 func NewRequest(ctx echo.Context) contracts.HttpRequest {
@@ -28,6 +30,43 @@ func NewRequest(ctx echo.Context) contracts.HttpRequest {
 	return request
 }
 
+// ClientIP 客户端的真实 ip，只有来自可信代理时才采用 Forwarded、X-Forwarded-For 和 X-Real-IP
+func (this *Request) ClientIP() string {
+	return this.resolveOrigin().ip
+}
+
+// RealIP 与 ClientIP 相同，覆盖 echo 无条件信任请求头的实现
+func (this *Request) RealIP() string {
+	return this.ClientIP()
+}
+
+// Scheme 客户端请求的协议，只有来自可信代理时才采用 Forwarded 和 X-Forwarded-Proto
+func (this *Request) Scheme() string {
+	return this.resolveOrigin().scheme
+}
+
+// Host 客户端请求的 host，只有来自可信代理时才采用 Forwarded 和 X-Forwarded-Host
+func (this *Request) Host() string {
+	return this.resolveOrigin().host
+}
+
+// Port 客户端请求的端口，只有来自可信代理时才采用 X-Forwarded-Port，否则取 host 中的端口或者协议的默认端口
+func (this *Request) Port() int {
+	return this.resolveOrigin().port
+}
+
+func (this *Request) resolveOrigin() *origin {
+	if this.origin == nil {
+		var proxies = this.proxies
+		if proxies == nil {
+			proxies = defaultProxies
+		}
+		var result = proxies.resolve(this.Request())
+		this.origin = &result
+	}
+	return this.origin
+}
+
 func (this *Request) Get(key string) (value interface{}) {
 	if value = this.Context.Get(key); value != nil && value != "" {
 		return value
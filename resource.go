@@ -0,0 +1,208 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/exceptions"
+	"github.com/labstack/echo/v4"
+	"reflect"
+	"strings"
+)
+
+// ResourceRegistrar 可以注册资源路由的路由组，NewGroup 和 Router.Group 返回的路由组都实现了该接口
+type ResourceRegistrar interface {
+	contracts.RouteGroup
+
+	// Resource 注册资源路由，包括 index、create、store、show、edit、update、destroy
+	Resource(name string, controller interface{}, args ...interface{}) contracts.RouteGroup
+
+	// ApiResource 注册 api 资源路由，不包括 create 和 edit
+	ApiResource(name string, controller interface{}, args ...interface{}) contracts.RouteGroup
+}
+
+// resourceAction 资源路由的动作
+type resourceAction struct {
+	name    string
+	method  string
+	methods []string
+	suffix  string
+	member  bool // 是否作用于单个资源，例如 show、edit、update、destroy
+}
+
+var (
+	resourceActions = []resourceAction{
+		{name: "index", method: "Index", methods: []string{echo.GET}},
+		{name: "create", method: "Create", methods: []string{echo.GET}, suffix: "/create"},
+		{name: "store", method: "Store", methods: []string{echo.POST}},
+		{name: "show", method: "Show", methods: []string{echo.GET}, member: true},
+		{name: "edit", method: "Edit", methods: []string{echo.GET}, suffix: "/edit", member: true},
+		{name: "update", method: "Update", methods: []string{echo.PUT, echo.PATCH}, member: true},
+		{name: "destroy", method: "Destroy", methods: []string{echo.DELETE}, member: true},
+	}
+	apiExcludedActions = []string{"create", "edit"}
+)
+
+type resourceOptions struct {
+	only       []string
+	except     []string
+	parameters map[string]string
+	shallow    bool
+}
+
+// ResourceOption 资源路由选项，可以和中间件、RouteOption 一起传给 Resource
+type ResourceOption func(options *resourceOptions)
+
+// Only 只注册指定的动作
+func Only(actions ...string) ResourceOption {
+	return func(options *resourceOptions) {
+		options.only = append(options.only, actions...)
+	}
+}
+
+// Except 不注册指定的动作
+func Except(actions ...string) ResourceOption {
+	return func(options *resourceOptions) {
+		options.except = append(options.except, actions...)
+	}
+}
+
+// Parameters 重命名资源的路由参数，例如 Parameters(map[string]string{"users": "admin_user"})
+func Parameters(parameters map[string]string) ResourceOption {
+	return func(options *resourceOptions) {
+		for resource, param := range parameters {
+			options.parameters[resource] = param
+		}
+	}
+}
+
+// Shallow 浅层嵌套，嵌套资源的 show、edit、update、destroy 不带父资源前缀
+func Shallow() ResourceOption {
+	return func(options *resourceOptions) {
+		options.shallow = true
+	}
+}
+
+// registerResource 把资源路由映射到 add 上，name 可以是 posts.comments 这样的嵌套资源
+func registerResource(
+	add func(method interface{}, path string, handler interface{}, middlewares ...interface{}),
+	name string, controller interface{}, excluded []string, args []interface{},
+) {
+	var (
+		options = resourceOptions{parameters: make(map[string]string)}
+		pipes   = make([]interface{}, 0, len(args))
+	)
+	for _, arg := range args {
+		if option, isOption := arg.(ResourceOption); isOption {
+			option(&options)
+		} else {
+			pipes = append(pipes, arg)
+		}
+	}
+
+	var (
+		resources  = strings.Split(name, ".")
+		resource   = resources[len(resources)-1]
+		parentPath = ""
+		value      = reflect.ValueOf(controller)
+		registered = 0
+	)
+	for _, parent := range resources[:len(resources)-1] {
+		parentPath += "/" + parent + "/:" + options.parameter(parent)
+	}
+
+	for _, action := range resourceActions {
+		if !options.allows(action.name) || contains(excluded, action.name) {
+			continue
+		}
+		var method = value.MethodByName(action.method)
+		if !method.IsValid() {
+			continue
+		}
+
+		var path = "/" + resource
+		if action.member {
+			path += "/:" + options.parameter(resource)
+		}
+		if !action.member || !options.shallow {
+			path = parentPath + path
+		}
+
+		add(action.methods, path+action.suffix, method.Interface(), append(pipes, Name(name+"."+action.name))...)
+		registered++
+	}
+
+	if registered == 0 { // 通常是方法使用指针接收者，却传入了控制器的值
+		panic(exceptions.New("resource controller has no action methods, pass a pointer if the methods use pointer receivers", contracts.Fields{
+			"resource":   name,
+			"controller": value.Type().String(),
+		}))
+	}
+}
+
+func (options resourceOptions) allows(action string) bool {
+	if len(options.only) > 0 && !contains(options.only, action) {
+		return false
+	}
+	return !contains(options.except, action)
+}
+
+// parameter 获取资源的路由参数名，默认为资源名的单数形式
+func (options resourceOptions) parameter(resource string) string {
+	if param, exists := options.parameters[resource]; exists {
+		return param
+	}
+	switch {
+	case strings.HasSuffix(resource, "ies"):
+		return strings.TrimSuffix(resource, "ies") + "y"
+	case strings.HasSuffix(resource, "sses"), strings.HasSuffix(resource, "xes"),
+		strings.HasSuffix(resource, "ches"), strings.HasSuffix(resource, "shes"), strings.HasSuffix(resource, "zzes"):
+		return strings.TrimSuffix(resource, "es")
+	case strings.HasSuffix(resource, "uses") && !isVowel(resource, len(resource)-5):
+		return strings.TrimSuffix(resource, "es") // buses、statuses，houses、causes 这类仍然只去掉 s
+	case strings.HasSuffix(resource, "ss"), strings.HasSuffix(resource, "us"), strings.HasSuffix(resource, "is"):
+		return resource // status、address、analysis 这类单数形式以 s 结尾的资源
+	case strings.HasSuffix(resource, "s"):
+		return strings.TrimSuffix(resource, "s")
+	}
+	return resource
+}
+
+// isVowel 判断 word 中 index 位置的字母是否是元音
+func isVowel(word string, index int) bool {
+	return index >= 0 && strings.IndexByte("aeiou", word[index]) >= 0
+}
+
+func contains(items []string, item string) bool {
+	for _, value := range items {
+		if value == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Resource 注册资源路由，controller 上的 Index、Create、Store、Show、Edit、Update、Destroy 方法会作为处理器
+// args 中可以混入中间件、RouteOption 和 ResourceOption
+func (group *group) Resource(name string, controller interface{}, args ...interface{}) contracts.RouteGroup {
+	registerResource(group.add, name, controller, nil, args)
+	return group
+}
+
+// ApiResource 注册 api 资源路由，不包括 create 和 edit
+func (group *group) ApiResource(name string, controller interface{}, args ...interface{}) contracts.RouteGroup {
+	registerResource(group.add, name, controller, apiExcludedActions, args)
+	return group
+}
+
+func (group *group) add(method interface{}, path string, handler interface{}, middlewares ...interface{}) {
+	group.Add(method, path, handler, middlewares...)
+}
+
+// Resource 注册资源路由，参考 group.Resource
+func (this *Router) Resource(name string, controller interface{}, args ...interface{}) {
+	registerResource(this.Add, name, controller, nil, args)
+}
+
+// ApiResource 注册 api 资源路由，不包括 create 和 edit
+func (this *Router) ApiResource(name string, controller interface{}, args ...interface{}) {
+	registerResource(this.Add, name, controller, apiExcludedActions, args)
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResourceParameter(t *testing.T) {
+	var options = resourceOptions{parameters: map[string]string{"people": "person"}}
+	for resource, expected := range map[string]string{
+		"photos":     "photo",
+		"categories": "category",
+		"buses":      "bus",
+		"statuses":   "status",
+		"campuses":   "campus",
+		"houses":     "house",
+		"classes":    "class",
+		"boxes":      "box",
+		"churches":   "church",
+		"dishes":     "dish",
+		"buzzes":     "buzz",
+		"status":     "status",
+		"analysis":   "analysis",
+		"people":     "person",
+		"sheep":      "sheep",
+	} {
+		if param := options.parameter(resource); param != expected {
+			t.Errorf("%s: expected %q, got %q", resource, expected, param)
+		}
+	}
+}
+
+type testPhotoController struct{}
+
+func (testPhotoController) Index() string              { return "index" }
+func (testPhotoController) Store() string              { return "store" }
+func (testPhotoController) Show(photo string) string   { return "show " + photo }
+func (testPhotoController) Update(photo string) string { return "update " + photo }
+
+type testCommentController struct{}
+
+func (testCommentController) Index(post string) string   { return "comments of " + post }
+func (testCommentController) Show(comment string) string { return "comment " + comment }
+
+func TestResourceRoutes(t *testing.T) {
+	var router = newTestRouter()
+	router.Resource("photos", testPhotoController{}, Except("update"))
+	router.ApiResource("posts.comments", testCommentController{}, Shallow())
+
+	for _, item := range []struct {
+		method, path, expected string
+	}{
+		{http.MethodGet, "/photos", "index"},
+		{http.MethodPost, "/photos", "store"},
+		{http.MethodGet, "/photos/1", "show 1"},
+		{http.MethodGet, "/posts/7/comments", "comments of 7"},
+		{http.MethodGet, "/comments/3", "comment 3"},
+	} {
+		if body := serve(router, item.method, item.path).Body.String(); body != item.expected {
+			t.Errorf("%s %s: expected %q, got %q", item.method, item.path, item.expected, body)
+		}
+	}
+	if response := serve(router, http.MethodPut, "/photos/1"); response.Code == http.StatusOK {
+		t.Error("excluded update action must not be registered")
+	}
+	for _, name := range []string{"photos.index", "photos.show", "posts.comments.index", "posts.comments.show"} {
+		if _, exists := router.named[name]; !exists {
+			t.Errorf("route %s is not named", name)
+		}
+	}
+}
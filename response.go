@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/goal-web/contracts"
 	"github.com/goal-web/supports/logs"
+	"github.com/labstack/echo/v4"
 	"go/types"
 	"net/http"
 	"os"
@@ -20,6 +21,10 @@ func HandleResponse(response interface{}, ctx contracts.HttpRequest) {
 		return
 	}
 	switch res := response.(type) {
+	case Exception:
+		logs.WithError(ctx.String(res.Status(), res.Error())).Debug("response error")
+	case *echo.HTTPError:
+		logs.WithError(ctx.String(res.Code, fmt.Sprint(res.Message))).Debug("response error")
 	case error:
 		logs.WithError(ctx.String(http.StatusInternalServerError, res.Error())).Debug("response error")
 	case string:
@@ -44,6 +49,9 @@ type Response struct {
 	String   string
 	FilePath string
 	File     *os.File
+	Redirect string
+	View     string
+	Data     interface{}
 }
 
 func StringResponse(str string, code ...int) contracts.HttpResponse {
@@ -80,11 +88,42 @@ func FileResponse(file interface{}) contracts.HttpResponse {
 	}
 }
 
+// RedirectResponse 重定向到 url，默认状态码为 302
+func RedirectResponse(url string, code ...int) contracts.HttpResponse {
+	status := http.StatusFound
+	if len(code) > 0 {
+		status = code[0]
+	}
+	return Response{
+		status:   status,
+		Redirect: url,
+	}
+}
+
+// ViewResponse 渲染模板，需要先通过 Router.SetRenderer 设置渲染器
+func ViewResponse(template string, data interface{}, code ...int) contracts.HttpResponse {
+	status := 200
+	if len(code) > 0 {
+		status = code[0]
+	}
+	return Response{
+		status: status,
+		View:   template,
+		Data:   data,
+	}
+}
+
 func (res Response) Status() int {
 	return res.status
 }
 
 func (res Response) Response(ctx contracts.HttpContext) error {
+	if res.Redirect != "" {
+		return ctx.Redirect(res.Status(), res.Redirect)
+	}
+	if res.View != "" {
+		return ctx.Render(res.Status(), res.View, res.Data)
+	}
 	if res.Json != nil {
 		return ctx.JSON(res.Status(), res.Json)
 	}
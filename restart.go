@@ -0,0 +1,234 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// inheritedListenersEnv 热重启时传给新进程的监听器，格式为 name=fd,name=fd
+	inheritedListenersEnv = "GOAL_HTTP_LISTENERS"
+
+	// inheritedPacketConnEnv 热重启时传给新进程的 HTTP/3 UDP 连接
+	inheritedPacketConnEnv = "GOAL_HTTP_PACKET_CONN"
+
+	// readyEnv 热重启时传给新进程的管道，新进程开始服务后写入一个字节通知旧进程
+	readyEnv = "GOAL_HTTP_READY"
+)
+
+var (
+	ListenerHandoffError = errors.New("listener does not support handoff")
+	NotReadyError        = errors.New("restarted process did not become ready")
+)
+
+type fileListener interface {
+	File() (*os.File, error)
+}
+
+// inheritedListeners 解析从旧进程继承的监听器，文件描述符只能使用一次，解析后清除环境变量
+func inheritedListeners() map[string]int {
+	var inherited = make(map[string]int)
+	var value = os.Getenv(inheritedListenersEnv)
+	_ = os.Unsetenv(inheritedListenersEnv)
+	for _, item := range strings.Split(value, ",") {
+		if index := strings.LastIndex(item, "="); index > 0 {
+			if fd, err := strconv.Atoi(item[index+1:]); err == nil {
+				inherited[item[:index]] = fd
+			}
+		}
+	}
+	return inherited
+}
+
+// inheritedPacketConn 从旧进程继承的 HTTP/3 UDP 连接，没有时返回 nil，解析后清除环境变量
+func inheritedPacketConn() *os.File {
+	var value = os.Getenv(inheritedPacketConnEnv)
+	_ = os.Unsetenv(inheritedPacketConnEnv)
+	if fd, err := strconv.Atoi(value); err == nil {
+		return os.NewFile(uintptr(fd), "inherited:http3")
+	}
+	return nil
+}
+
+// Restart 热重启，启动新进程并把所有监听器交给它，新进程开始服务后优雅关闭当前进程的服务，等待处理中的请求完成或者 ctx 结束
+// 新旧进程共享监听的 socket，重启期间的连接不会被拒绝，新进程在 ReadyTimeout 内没有就绪时结束新进程，当前进程继续服务
+func (this *Router) Restart(ctx context.Context) error {
+	this.serverMutex.Lock()
+	var (
+		names = make([]string, 0, len(this.listeners))
+		files = make([]*os.File, 0, len(this.listeners))
+	)
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+	for name, listener := range this.listeners {
+		var handoff, ok = listener.(fileListener)
+		if !ok {
+			this.serverMutex.Unlock()
+			return ListenerHandoffError
+		}
+		var file, err = handoff.File()
+		if err != nil {
+			this.serverMutex.Unlock()
+			return err
+		}
+		names = append(names, fmt.Sprintf("%s=%d", name, listenFdsStart+len(files)))
+		files = append(files, file)
+	}
+	var env = restartEnv()
+	if this.http3Conn != nil { // UDP 端口同样交给新进程，否则新进程无法绑定，HTTP/3 会在重启后消失
+		var handoff, ok = this.http3Conn.(fileListener)
+		if !ok {
+			this.serverMutex.Unlock()
+			return ListenerHandoffError
+		}
+		var file, err = handoff.File()
+		if err != nil {
+			this.serverMutex.Unlock()
+			return err
+		}
+		env = append(env, fmt.Sprintf("%s=%d", inheritedPacketConnEnv, listenFdsStart+len(files)))
+		files = append(files, file)
+	}
+	this.serverMutex.Unlock()
+
+	var executable, err = os.Executable()
+	if err != nil {
+		return err
+	}
+	ready, notify, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+
+	var command = exec.Command(executable, os.Args[1:]...)
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+	command.ExtraFiles = append(files, notify)
+	command.Env = append(env,
+		inheritedListenersEnv+"="+strings.Join(names, ","),
+		fmt.Sprintf("%s=%d", readyEnv, listenFdsStart+len(files)),
+	)
+	err = command.Start()
+	_ = notify.Close() // 只有新进程持有写端，新进程退出时读端返回 EOF
+	if err != nil {
+		return err
+	}
+
+	if err = waitReady(ready, this.readyTimeout()); err != nil {
+		_ = command.Process.Kill()
+		_ = command.Wait()
+		return err
+	}
+	go func() { _ = command.Wait() }()
+
+	// 新进程继续使用 unix socket 文件，关闭时不能删除
+	this.serverMutex.Lock()
+	for _, listener := range this.listeners {
+		if unixListener, isUnix := listener.(*net.UnixListener); isUnix {
+			unixListener.SetUnlinkOnClose(false)
+		}
+	}
+	this.serverMutex.Unlock()
+
+	return this.Shutdown(ctx)
+}
+
+// Shutdown 优雅关闭所有监听器上的 http 服务，等待处理中的请求完成或者 ctx 结束
+// Router.Start 在 Shutdown 返回后才返回，避免应用在请求处理完之前退出
+func (this *Router) Shutdown(ctx context.Context) error {
+	this.serverMutex.Lock()
+	this.draining.Add(1)
+	defer this.draining.Done()
+	var servers = make([]*http.Server, 0, len(this.servers))
+	for name, server := range this.servers {
+		servers = append(servers, server)
+		delete(this.servers, name)
+		delete(this.listeners, name)
+	}
+	this.closeHttp3()
+	this.serverMutex.Unlock()
+
+	var result error
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// readyTimeout 等待新进程就绪的时间，默认为 30 秒
+func (this *Router) readyTimeout() time.Duration {
+	if this.config.ReadyTimeout > 0 {
+		return this.config.ReadyTimeout
+	}
+	return 30 * time.Second
+}
+
+// waitReady 等待新进程通过管道通知已经开始服务，新进程退出或者超时返回 NotReadyError
+func waitReady(ready *os.File, timeout time.Duration) error {
+	var result = make(chan error, 1)
+	go func() {
+		var _, err = ready.Read(make([]byte, 1))
+		result <- err
+	}()
+
+	var timer = time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		if err != nil {
+			return NotReadyError
+		}
+		return nil
+	case <-timer.C:
+		return NotReadyError
+	}
+}
+
+// notifyReady 由热重启启动的进程开始服务后通知旧进程
+func notifyReady() {
+	var value = os.Getenv(readyEnv)
+	_ = os.Unsetenv(readyEnv)
+	if value == "" {
+		return
+	}
+	var fd, err = strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	if file := os.NewFile(uintptr(fd), "ready"); file != nil {
+		_, _ = file.Write([]byte{1})
+		_ = file.Close()
+	}
+}
+
+// restartEnv 新进程的环境变量，去掉旧进程继承的监听器、UDP 连接、就绪管道和 systemd 的变量
+func restartEnv() []string {
+	var env = make([]string, 0)
+	for _, item := range os.Environ() {
+		if !strings.HasPrefix(item, inheritedListenersEnv+"=") && !strings.HasPrefix(item, inheritedPacketConnEnv+"=") &&
+			!strings.HasPrefix(item, readyEnv+"=") && !strings.HasPrefix(item, "LISTEN_") {
+			env = append(env, item)
+		}
+	}
+	return env
+}
+
+// serving 判断当前进程是否还有运行中的服务
+func (this *Router) serving() bool {
+	this.serverMutex.Lock()
+	defer this.serverMutex.Unlock()
+	return len(this.servers) > 0
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRestartReadyHandshake(t *testing.T) {
+	var ready, notify, err = os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ready.Close()
+
+	// 复制写端交给 notifyReady，由它关闭一次，测试只关闭自己持有的 notify
+	fd, err := syscall.Dup(int(notify.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = notify.Close()
+
+	t.Setenv(readyEnv, strconv.Itoa(fd))
+	notifyReady()
+
+	if _, exists := os.LookupEnv(readyEnv); exists {
+		t.Fatal("ready pipe is still in the environment")
+	}
+	if err = waitReady(ready, time.Second); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestRestartNotReady(t *testing.T) {
+	var ready, notify, err = os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ready.Close()
+
+	if err = waitReady(ready, 50*time.Millisecond); err != NotReadyError {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	_ = notify.Close() // 新进程没有通知就退出了
+	if err = waitReady(ready, time.Second); err != NotReadyError {
+		t.Fatalf("expected exited process, got %v", err)
+	}
+}
+
+func TestStartWaitsForShutdownToDrain(t *testing.T) {
+	var (
+		router   = newTestRouter()
+		entered  = make(chan struct{})
+		release  = make(chan struct{})
+		started  = make(chan error, 1)
+		shutdown = make(chan error, 1)
+		response = make(chan string, 1)
+	)
+	router.Get("/slow", func() string {
+		close(entered)
+		<-release
+		return "done"
+	})
+	go func() { started <- router.Start("127.0.0.1:0") }()
+	t.Cleanup(func() { _ = router.Close() })
+	var address = waitListener(t, router, DefaultListener).Addr().String()
+
+	go func() {
+		var result, err = http.Get("http://" + address + "/slow")
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer result.Body.Close()
+		var body, _ = io.ReadAll(result.Body)
+		response <- string(body)
+	}()
+	<-entered
+	go func() { shutdown <- router.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-started:
+		t.Fatalf("Start returned %v while a request is still in flight", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	if body := <-response; body != "done" {
+		t.Fatalf("in-flight request did not complete: %q", body)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("unexpected shutdown error %v", err)
+	}
+	if err := <-started; err != http.ErrServerClosed {
+		t.Fatalf("unexpected start error %v", err)
+	}
+}
+
+func TestInheritHttp3PacketConn(t *testing.T) {
+	var conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close() // 旧进程在新进程就绪前一直持有 UDP 连接
+	file, err := conn.(*net.UDPConn).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, err := syscall.Dup(int(file.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = file.Close()
+
+	t.Setenv(inheritedPacketConnEnv, strconv.Itoa(fd))
+	var router = newTestRouter()
+	startTestServer(t, router, Config{
+		Http3:        newTestHttp3Server(nil),
+		Http3Address: conn.LocalAddr().String(),
+	})
+
+	if _, exists := os.LookupEnv(inheritedPacketConnEnv); exists {
+		t.Fatal("inherited packet conn is still in the environment")
+	}
+	router.serverMutex.Lock()
+	defer router.serverMutex.Unlock()
+	if router.http3Conn == nil || router.http3Conn.LocalAddr().String() != conn.LocalAddr().String() {
+		t.Fatalf("http3 did not reuse the inherited udp socket %v", router.http3Conn)
+	}
+}
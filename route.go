@@ -1,12 +1,128 @@
 package http
 
-import "github.com/goal-web/contracts"
+import (
+	"github.com/goal-web/container"
+	"github.com/goal-web/contracts"
+	"regexp"
+)
+
+// attributes 路由和路由组共有的属性
+type attributes struct {
+	name        string
+	domain      *domainPattern
+	version     *apiVersion
+	deprecation *deprecation
+	excluded    []interface{} // 排除的中间件名称或者 MagicalFunc
+	isolated    bool          // 路由组不继承父组的中间件
+	listeners   []string
+	constraints map[string]*regexp.Regexp
+	inputs      []string // 路径参数之外的标量参数依次绑定的请求字段
+}
+
+// RouteOption 路由选项，可以和中间件一起传给路由或者路由组
+type RouteOption func(attributes *attributes)
+
+// attributesOf 获取路由或者路由组的属性，外部实现的路由没有属性
+func attributesOf(item interface{}) *attributes {
+	switch v := item.(type) {
+	case *route:
+		return &v.attributes
+	case *group:
+		return &v.attributes
+	}
+	return nil
+}
+
+func newAttributes(options []RouteOption) attributes {
+	var attrs = attributes{
+		constraints: make(map[string]*regexp.Regexp),
+	}
+	for _, option := range options {
+		option(&attrs)
+	}
+	return attrs
+}
+
+// inheritAttributes 合并父组和子组的属性，名称作为前缀拼接，约束和排除的中间件累加，其他属性子组优先
+func inheritAttributes(parent, child *attributes) *attributes {
+	if parent == nil || child == nil {
+		if child == nil {
+			return parent
+		}
+		return child
+	}
+	var merged = &attributes{
+		name:        parent.name + child.name,
+		domain:      parent.domain,
+		version:     parent.version,
+		deprecation: parent.deprecation,
+		excluded:    append(append([]interface{}{}, parent.excluded...), child.excluded...),
+		constraints: mergeConstraints(parent, child),
+	}
+	if child.domain != nil {
+		merged.domain = child.domain
+	}
+	if child.version != nil {
+		merged.version = child.version
+	}
+	if child.deprecation != nil {
+		merged.deprecation = child.deprecation
+	}
+	merged.listeners = parent.listeners
+	if len(child.listeners) > 0 {
+		merged.listeners = child.listeners
+	}
+	return merged
+}
+
+// WithoutInheritedMiddleware 路由组不继承父组的中间件，名称前缀、域名、约束等属性仍然继承
+func WithoutInheritedMiddleware() RouteOption {
+	return func(attributes *attributes) {
+		attributes.isolated = true
+	}
+}
+
+// Name 设置路由的名称，作用于路由组时作为组内路由和子组的名称前缀，例如 Name("admin.")
+func Name(name string) RouteOption {
+	return func(attributes *attributes) {
+		attributes.name = name
+	}
+}
+
+// Inputs 声明路径参数之外的标量参数依次绑定的请求字段，字段从 query、表单或者 json 请求体中读取，只作用于路由
+// 例如 router.Get("/users", func(page, size int) {...}, Inputs("page", "size")) 绑定 ?page=2&size=20，缺少的字段注入零值
+func Inputs(names ...string) RouteOption {
+	return func(attributes *attributes) {
+		attributes.inputs = append(attributes.inputs, names...)
+	}
+}
 
 type route struct {
+	attributes
 	method      []string
 	path        string
 	middlewares []contracts.MagicalFunc
 	handler     contracts.MagicalFunc
+	action      string // "Controller@Method" 形式的处理器，装配时解析
+}
+
+// newRoute 创建路由，handler 可以是函数或者 "Controller@Method"
+func newRoute(methods []string, path string, handler interface{}, middlewares ...interface{}) *route {
+	var (
+		pipes, options = parseRouteArgs(middlewares...)
+		instance       = &route{
+			attributes:  newAttributes(options),
+			method:      methods,
+			path:        path,
+			middlewares: pipes,
+		}
+	)
+	if action, isAction := handler.(string); isAction {
+		instance.action = action
+	} else {
+		instance.handler = container.NewMagicalFunc(handler)
+	}
+	return instance
 }
 
 func (route *route) Middlewares() []contracts.MagicalFunc {
@@ -24,3 +140,8 @@ func (route *route) Path() string {
 func (route *route) Handler() contracts.MagicalFunc {
 	return route.handler
 }
+
+// Name 获取路由的名称
+func (route *route) Name() string {
+	return route.name
+}
@@ -0,0 +1,35 @@
+package http
+
+// RouteInfo 装配后的路由信息，用于查看路由最终的中间件链等
+type RouteInfo struct {
+	Methods     []string
+	Path        string
+	Name        string
+	Domain      string
+	Version     string
+	Listeners   []string
+	Middlewares []string // 最终执行的中间件，包括全局中间件，按执行顺序排列
+}
+
+// RouteList 获取装配后的所有路由，路由装配之后才可以使用
+func (this *Router) RouteList() []RouteInfo {
+	var list = make([]RouteInfo, 0, len(this.mounted))
+	for _, mounted := range this.mounted {
+		var info = RouteInfo{
+			Methods:     mounted.route.Method(),
+			Path:        mounted.path,
+			Name:        mounted.name,
+			Version:     mounted.version,
+			Listeners:   mounted.listeners,
+			Middlewares: make([]string, 0, len(mounted.middlewares)),
+		}
+		if mounted.domain != nil {
+			info.Domain = mounted.domain.pattern
+		}
+		for _, middleware := range mounted.middlewares {
+			info.Middlewares = append(info.Middlewares, middlewareName(middleware))
+		}
+		list = append(list, info)
+	}
+	return list
+}
@@ -4,10 +4,13 @@ import (
 	"errors"
 	"github.com/goal-web/container"
 	"github.com/goal-web/contracts"
-	"github.com/goal-web/pipeline"
-	"github.com/goal-web/supports/exceptions"
 	"github.com/labstack/echo/v4"
+	"net"
+	"net/http"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 )
 
 var (
@@ -19,14 +22,27 @@ var (
 	})
 )
 
-func New(container contracts.Application) contracts.Router {
+// New 创建路由器，engine 为空时使用 echo 引擎
+func New(container contracts.Application, engine ...Engine) contracts.Router {
+	if len(engine) == 0 {
+		engine = append(engine, NewEchoEngine())
+	}
 	router := &Router{
 		app:         container,
 		events:      container.Get("events").(contracts.EventDispatcher),
-		echo:        echo.New(),
+		engine:      engine[0],
 		routes:      make([]contracts.Route, 0),
 		groups:      make([]contracts.RouteGroup, 0),
 		middlewares: make([]contracts.MagicalFunc, 0),
+		bindings:    make(map[string]*modelBinding),
+		controllers: make(map[string]reflect.Type),
+		versioning:  VersioningConfig{Header: "X-Api-Version"},
+		servers:     make(map[string]*http.Server),
+		listeners:   make(map[string]net.Listener),
+		proxies:     defaultProxies,
+
+		aliases:          make(map[string]contracts.MagicalFunc),
+		middlewareGroups: make(map[string][]contracts.MagicalFunc),
 	}
 
 	router.Use(router.recovery)
@@ -37,12 +53,47 @@ func New(container contracts.Application) contracts.Router {
 type Router struct {
 	events contracts.EventDispatcher
 	app    contracts.Application
-	echo   *echo.Echo
+	engine Engine
 	groups []contracts.RouteGroup
 	routes []contracts.Route
 
 	// 全局中间件
 	middlewares []contracts.MagicalFunc
+
+	// 中间件别名、中间件组以及命名中间件的优先级
+	aliases          map[string]contracts.MagicalFunc
+	middlewareGroups map[string][]contracts.MagicalFunc
+	priority         []string
+
+	// 路由模型绑定
+	bindings map[string]*modelBinding
+
+	// 注册的控制器
+	controllers map[string]reflect.Type
+
+	// 装配后的路由以及带名称的路由
+	mounted []*mountedRoute
+	named   map[string]*mountedRoute
+
+	// api 版本协商配置、注册的版本以及装配后每个路由支持的版本
+	versioning    VersioningConfig
+	versions      []string
+	routeVersions map[string][]string
+
+	// 兜底路由以及装配后每个路径允许的方法
+	fallback  *route
+	fallbacks []*mountedRoute
+	allowed   map[string][]string
+
+	// 服务配置以及运行中的服务
+	config      Config
+	servers     map[string]*http.Server
+	listeners   map[string]net.Listener
+	http3Conn   net.PacketConn
+	proxies     *trustedProxies
+	serverMutex sync.Mutex
+	draining    sync.WaitGroup
+	prepareOnce sync.Once
 }
 
 func (this *Router) Group(prefix string, middlewares ...interface{}) contracts.RouteGroup {
@@ -54,14 +105,20 @@ func (this *Router) Group(prefix string, middlewares ...interface{}) contracts.R
 }
 
 func (this *Router) Close() error {
-	return this.echo.Close()
+	return this.shutdown()
 }
 
 func (this *Router) Static(path, directory string) {
 	if strings.HasPrefix(directory, "/") {
 		directory = this.app.Get("path").(string) + "/" + directory
 	}
-	this.echo.Static(path, directory)
+	var handler = func(request *Request) error {
+		return request.File(filepath.Join(directory, filepath.Clean("/"+request.Param("*"))))
+	}
+	if path = strings.TrimSuffix(path, "/"); path != "" {
+		this.Get(path, handler)
+	}
+	this.Get(path+"/*", handler)
 }
 
 func (this *Router) Get(path string, handler interface{}, middlewares ...interface{}) {
@@ -97,9 +154,9 @@ func (this *Router) Use(middlewares ...interface{}) {
 		if magicalFunc, ok := middleware.(contracts.MagicalFunc); ok {
 			this.middlewares = append(this.middlewares, magicalFunc)
 		} else if echoMiddleware, isEchoFunc := middleware.(echo.MiddlewareFunc); isEchoFunc {
-			this.echo.Use(echoMiddleware)
+			this.engine.Use(echoMiddleware)
 		} else {
-			this.middlewares = append(this.middlewares, container.NewMagicalFunc(middleware))
+			this.middlewares = append(this.middlewares, newMagicalMiddleware(middleware))
 		}
 	}
 }
@@ -114,82 +171,23 @@ func (this *Router) Add(method interface{}, path string, handler interface{}, mi
 	default:
 		panic(errors.New("method 只能接收 string 或者 []string"))
 	}
-	this.routes = append(this.routes, &route{
-		method:      methods,
-		path:        path,
-		middlewares: convertToMiddlewares(middlewares...),
-		handler:     container.NewMagicalFunc(handler),
-	})
-}
-
-func (this *Router) mountGroup(group contracts.RouteGroup) {
-	this.mountRoutes(group.Routes(), group.Middlewares()...)
-
-	for _, routeGroup := range group.Groups() {
-		this.mountGroup(routeGroup)
-	}
+	this.routes = append(this.routes, newRoute(methods, path, handler, middlewares...))
 }
 
 // Start 启动 httpserver
 func (this *Router) Start(address string) error {
 
-	this.mountRoutes(this.routes)
+	this.prepare()
 
-	for _, routeGroup := range this.groups {
-		this.mountGroup(routeGroup)
-	}
+	return this.serve(address)
+}
 
-	this.echo.HTTPErrorHandler = func(err error, context echo.Context) {
-		if result := this.app.StaticCall(exceptionHandler, Exception{Exception: exceptions.WithError(err, contracts.Fields{
-			"status": context.Response().Status,
-		}), Request: NewRequest(context)})[0]; result != nil {
-			HandleResponse(result, NewRequest(context))
-		}
-	}
-	this.echo.Debug = this.app.Debug()
-
-	return this.echo.Start(address)
-}
-
-// mountRoutes 装配路由
-func (this *Router) mountRoutes(routes []contracts.Route, middlewares ...contracts.MagicalFunc) {
-	for _, routeItem := range routes {
-		(func(routeInstance contracts.Route) {
-			this.echo.Match(routeInstance.Method(), routeInstance.Path(), func(context echo.Context) error {
-				request := NewRequest(context)
-				defer func() {
-					this.events.Dispatch(&RequestAfter{request})
-				}()
-
-				// 触发钩子
-				this.events.Dispatch(&RequestBefore{request})
-
-				pipes := append(this.middlewares, middlewares...)
-				pipes = append(pipes, routeInstance.Middlewares()...)
-
-				var result interface{}
-				if len(pipes) == 0 {
-					results := this.app.StaticCall(routeInstance.Handler(), request)
-					if len(results) > 0 {
-						result = results[0]
-					}
-				} else {
-					result = pipeline.Static(this.app).SendStatic(request).
-						ThroughStatic(
-							this.middlewares...,
-						).
-						ThroughStatic(
-							append(middlewares, routeInstance.Middlewares()...)...,
-						).
-						ThenStatic(routeInstance.Handler())
-				}
-
-				this.events.Dispatch(&ResponseBefore{request})
-
-				HandleResponse(result, request)
-
-				return nil
-			})
-		})(routeItem)
-	}
+// prepare 装配路由并设置错误处理器和调试模式，只执行一次
+func (this *Router) prepare() {
+	this.prepareOnce.Do(func() {
+		this.mount()
+
+		this.engine.SetErrorHandler(this.handleError)
+		this.engine.SetDebug(this.app.Debug())
+	})
 }
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"fmt"
+	"github.com/goal-web/supports/logs"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+)
+
+// Http3Server HTTP/3 (QUIC) 服务，框架不直接依赖 QUIC 实现，可以用 quic-go 的 http3.Server 适配
+type Http3Server interface {
+	// Serve 在路由器绑定好的 UDP 连接上处理请求，返回前不需要关闭 conn，证书等配置由实现负责
+	Serve(conn net.PacketConn, handler http.Handler) error
+
+	// Close 关闭服务
+	Close() error
+}
+
+// Configure 设置服务配置，例如 h2c、HTTP/3 和可信代理
+func (this *Router) Configure(config Config) {
+	this.config = config
+	if len(config.TrustedProxies) > 0 {
+		this.proxies = newTrustedProxies(config.TrustedProxies)
+	} else {
+		this.proxies = defaultProxies
+	}
+}
+
+// serve 在默认地址和配置的所有监听器上启动 http 服务，任意一个服务停止并且优雅关闭结束后返回
+// 开启 HTTP/3 时，UDP 端口绑定成功后才通过 Alt-Svc 头告知客户端，绑定或者启动失败时不发送 Alt-Svc，只使用 tcp 上的服务
+func (this *Router) serve(address string) error {
+	var configs = map[string]ListenerConfig{}
+	if address != "" {
+		configs[DefaultListener] = ListenerConfig{Address: address}
+	}
+	for name, config := range this.config.Listeners {
+		configs[name] = config
+	}
+	if len(configs) == 0 {
+		return NoListenerError
+	}
+
+	var (
+		handler    http.Handler = this.engine
+		packetFile              = inheritedPacketConn()
+	)
+
+	if this.config.Http3 != nil {
+		var (
+			http3Address = this.config.Http3Address
+			available    int32
+		)
+		if http3Address == "" {
+			http3Address = address
+		}
+		if conn, err := listenPacket(http3Address, packetFile); err != nil {
+			logs.WithError(err).Error("http3 服务无法监听，只使用 tcp 上的 http 服务")
+		} else {
+			this.serverMutex.Lock()
+			this.http3Conn = conn
+			this.serverMutex.Unlock()
+			atomic.StoreInt32(&available, 1)
+			go func() {
+				if err := this.config.Http3.Serve(conn, this.engine); err != nil && err != http.ErrServerClosed {
+					atomic.StoreInt32(&available, 0)
+					logs.WithError(err).Error("http3 服务无法启动，只使用 tcp 上的 http 服务")
+				}
+			}()
+			handler = altSvc(handler, conn.LocalAddr(), &available)
+		}
+	}
+	if packetFile != nil {
+		_ = packetFile.Close()
+	}
+
+	if this.config.H2C {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+
+	var (
+		errs      = make(chan error, len(configs))
+		inherited = inheritedListeners()
+	)
+	this.serverMutex.Lock()
+	for name, config := range configs {
+		var listener, err = listenOrInherit(name, config, inherited)
+		if err != nil {
+			this.serverMutex.Unlock()
+			closeInherited(inherited)
+			_ = this.shutdown()
+			return err
+		}
+		var server = &http.Server{Handler: handler, BaseContext: listenerContext(name)}
+		this.servers[name], this.listeners[name] = server, listener
+		go func() {
+			errs <- server.Serve(listener)
+		}()
+	}
+	this.serverMutex.Unlock()
+	closeInherited(inherited)
+	notifyReady()
+	this.events.Dispatch(&ServeStarted{})
+
+	var err = <-errs
+	this.draining.Wait()
+	return err
+}
+
+// shutdown 关闭所有监听器上的 http 服务以及 HTTP/3 服务
+func (this *Router) shutdown() error {
+	this.serverMutex.Lock()
+	defer this.serverMutex.Unlock()
+
+	this.closeHttp3()
+
+	var result error
+	for name, server := range this.servers {
+		if err := server.Close(); err != nil {
+			result = err
+		}
+		delete(this.servers, name)
+		delete(this.listeners, name)
+	}
+	return result
+}
+
+// closeHttp3 关闭 HTTP/3 服务以及它使用的 UDP 连接，需要在持有 serverMutex 时调用
+func (this *Router) closeHttp3() {
+	if this.config.Http3 == nil {
+		return
+	}
+	if err := this.config.Http3.Close(); err != nil {
+		logs.WithError(err).Info("http3 服务关闭报错")
+	}
+	if this.http3Conn != nil {
+		_ = this.http3Conn.Close()
+		this.http3Conn = nil
+	}
+}
+
+// listenOrInherit 优先使用热重启时从旧进程继承的监听器，使用过的文件描述符从 inherited 中删除
+func listenOrInherit(name string, config ListenerConfig, inherited map[string]int) (net.Listener, error) {
+	if fd, exists := inherited[name]; exists {
+		delete(inherited, name)
+		return listenFd(fd, "inherited:"+name)
+	}
+	return listen(config)
+}
+
+// listenPacket 优先使用热重启时从旧进程继承的 UDP 连接
+func listenPacket(address string, inherited *os.File) (net.PacketConn, error) {
+	if inherited != nil {
+		return net.FilePacketConn(inherited)
+	}
+	return net.ListenPacket("udp", address)
+}
+
+// closeInherited 关闭没有使用的继承文件描述符，例如新版本的配置中已经去掉的监听器，否则旧的 socket 会一直占用端口
+func closeInherited(inherited map[string]int) {
+	for name, fd := range inherited {
+		if file := os.NewFile(uintptr(fd), "inherited:"+name); file != nil {
+			_ = file.Close()
+		}
+		delete(inherited, name)
+	}
+}
+
+// altSvc 在 HTTP/3 可用时添加 Alt-Svc 响应头，端口取实际绑定的地址，配置为 :0 或者只有主机名时也能得到正确的端口
+func altSvc(handler http.Handler, bound net.Addr, available *int32) http.Handler {
+	var value = `h3=":443"; ma=86400`
+	if _, port, err := net.SplitHostPort(bound.String()); err == nil {
+		value = fmt.Sprintf(`h3=":%s"; ma=86400`, port)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(available) == 1 {
+			w.Header().Set("Alt-Svc", value)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"golang.org/x/net/http2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// testHttp3Server 模拟 HTTP/3 服务，err 不为空时 Serve 立即返回该错误，否则阻塞到 Close
+type testHttp3Server struct {
+	err    error
+	closed chan struct{}
+}
+
+func newTestHttp3Server(err error) *testHttp3Server {
+	return &testHttp3Server{err: err, closed: make(chan struct{})}
+}
+
+func (server *testHttp3Server) Serve(net.PacketConn, http.Handler) error {
+	if server.err != nil {
+		return server.err
+	}
+	<-server.closed
+	return http.ErrServerClosed
+}
+
+func (server *testHttp3Server) Close() error {
+	select {
+	case <-server.closed:
+	default:
+		close(server.closed)
+	}
+	return nil
+}
+
+// startTestServer 在随机端口上启动路由器，返回 tcp 地址
+func startTestServer(t *testing.T, router *Router, config Config) string {
+	router.Get("/", func() string { return "ok" })
+	router.Configure(config)
+	go func() { _ = router.Start("127.0.0.1:0") }()
+	t.Cleanup(func() { _ = router.Close() })
+	return waitListener(t, router, DefaultListener).Addr().String()
+}
+
+// waitListener 等待路由器在指定的监听器上开始服务
+func waitListener(t *testing.T, router *Router, name string) net.Listener {
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		router.serverMutex.Lock()
+		var listener = router.listeners[name]
+		router.serverMutex.Unlock()
+		if listener != nil {
+			return listener
+		}
+	}
+	t.Fatalf("listener %s did not start", name)
+	return nil
+}
+
+func getAltSvc(t *testing.T, address string) string {
+	var response, err = http.Get("http://" + address + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+	return response.Header.Get("Alt-Svc")
+}
+
+func TestAltSvcAfterHttp3Bound(t *testing.T) {
+	for _, http3Address := range []string{"127.0.0.1:0", ""} { // 为空时使用默认地址，同样是随机端口
+		var (
+			router  = newTestRouter()
+			address = startTestServer(t, router, Config{
+				Http3:        newTestHttp3Server(nil),
+				Http3Address: http3Address,
+			})
+		)
+
+		router.serverMutex.Lock()
+		var port = router.http3Conn.LocalAddr().(*net.UDPAddr).Port
+		router.serverMutex.Unlock()
+		if value := getAltSvc(t, address); port == 0 || value != fmt.Sprintf(`h3=":%d"; ma=86400`, port) {
+			t.Errorf("%q: unexpected Alt-Svc %q for udp port %d", http3Address, value, port)
+		}
+	}
+}
+
+func TestAltSvcFallbackWhenHttp3CannotBind(t *testing.T) {
+	var occupied, err = net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer occupied.Close()
+
+	var address = startTestServer(t, newTestRouter(), Config{
+		Http3:        newTestHttp3Server(nil),
+		Http3Address: occupied.LocalAddr().String(),
+	})
+
+	if value := getAltSvc(t, address); value != "" {
+		t.Fatalf("Alt-Svc %q is advertised while HTTP/3 is not bound", value)
+	}
+}
+
+func TestAltSvcFallbackWhenHttp3Fails(t *testing.T) {
+	var address = startTestServer(t, newTestRouter(), Config{
+		Http3:        newTestHttp3Server(errors.New("invalid certificate")),
+		Http3Address: "127.0.0.1:0",
+	})
+
+	for deadline := time.Now().Add(2 * time.Second); getAltSvc(t, address) != ""; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Alt-Svc is still advertised after HTTP/3 failed")
+		}
+	}
+}
+
+func TestH2cFallback(t *testing.T) {
+	var address = startTestServer(t, newTestRouter(), Config{H2C: true})
+
+	var response, err = http.Get("http://" + address + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.ProtoMajor != 1 || response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected http/1 response %s %d", response.Proto, response.StatusCode)
+	}
+
+	var client = &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, address string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, address)
+		},
+	}}
+	if response, err = client.Get("http://" + address + "/"); err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.ProtoMajor != 2 || response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected h2c response %s %d", response.Proto, response.StatusCode)
+	}
+}
+
+func TestServeWithoutListener(t *testing.T) {
+	if err := newTestRouter().Start(""); err != NoListenerError {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestCloseUnusedInheritedListeners(t *testing.T) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var address = listener.Addr().String()
+	file, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 复制一个不属于任何 *os.File 的文件描述符，模拟从旧进程继承，只由 closeInherited 关闭一次
+	fd, err := syscall.Dup(int(file.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = file.Close()
+	_ = listener.Close()
+
+	t.Setenv(inheritedListenersEnv, "removed="+strconv.Itoa(fd))
+	var inherited = inheritedListeners()
+	if value, exists := os.LookupEnv(inheritedListenersEnv); exists {
+		t.Fatalf("inherited listeners are still in the environment: %q", value)
+	}
+	closeInherited(inherited)
+
+	// 继承的 socket 关闭后端口才能重新监听
+	if listener, err = net.Listen("tcp", address); err != nil {
+		t.Fatalf("unused inherited listener still holds %s: %v", address, err)
+	}
+	_ = listener.Close()
+}
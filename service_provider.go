@@ -1,11 +1,16 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"github.com/goal-web/contracts"
 	"github.com/goal-web/supports/logs"
 	"github.com/goal-web/supports/utils"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 type ServiceProvider struct {
@@ -28,8 +33,11 @@ func (this *ServiceProvider) Start() error {
 		this.app.Call(collector)
 	}
 
-	err := this.app.Call(func(router contracts.Router, config contracts.Config) error {
+	err := this.app.Call(func(router contracts.Router, config contracts.Config, dispatcher contracts.EventDispatcher) error {
 		httpConfig := config.Get("http").(Config)
+		if httpConfig.HotRestart { // 监听器绑定之后才能交给新进程
+			dispatcher.Register((&ServeStarted{}).Event(), restartListener{provider: this, router: router.(*Router), config: httpConfig})
+		}
 		return router.Start(
 			utils.StringOr(
 				httpConfig.Address,
@@ -50,7 +58,52 @@ func (this *ServiceProvider) Start() error {
 func (this *ServiceProvider) Register(app contracts.Application) {
 	this.app = app
 
-	app.Singleton("Router", func() contracts.Router {
-		return New(this.app)
+	app.Singleton("Router", func(config contracts.Config) contracts.Router {
+		var (
+			httpConfig = config.Get("http").(Config)
+			router     = New(this.app, NewEngine(httpConfig.Engine)).(*Router)
+		)
+		router.Configure(httpConfig)
+		return router
 	})
 }
+
+// watchRestart 收到重启信号后热重启，旧进程的服务关闭后停止应用，此时触发 ServeClosed
+func (this *ServiceProvider) watchRestart(router *Router, config Config) {
+	var (
+		signals = make(chan os.Signal, 1)
+		timeout = config.ShutdownTimeout
+	)
+	if config.RestartSignal == nil {
+		config.RestartSignal = syscall.SIGHUP
+	}
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	signal.Notify(signals, config.RestartSignal)
+	defer signal.Stop(signals)
+
+	for range signals {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := router.Restart(ctx)
+		cancel()
+		if err != nil {
+			logs.WithError(err).Error("http 服务热重启失败")
+		}
+		if !router.serving() { // 监听器已经交给新进程
+			this.app.Stop()
+			return
+		}
+	}
+}
+
+// restartListener 服务开始后监听重启信号
+type restartListener struct {
+	provider *ServiceProvider
+	router   *Router
+	config   Config
+}
+
+func (listener restartListener) Handle(contracts.Event) {
+	go listener.provider.watchRestart(listener.router, listener.config)
+}
@@ -0,0 +1,99 @@
+package sse
+
+import (
+	"context"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/logs"
+	"sync"
+)
+
+// Broker 跨节点分发 sse 消息的发布订阅后端
+type Broker interface {
+	// Publish 发布消息到指定频道
+	Publish(channel string, payload []byte) error
+
+	// Subscribe 订阅指定频道，返回取消订阅的函数
+	Subscribe(channel string, handler func(payload []byte)) (unsubscribe func(), err error)
+}
+
+// MemoryBroker 进程内的 broker，适用于单节点部署和测试
+type MemoryBroker struct {
+	mutex       sync.RWMutex
+	subscribers map[string]map[uint64]func(payload []byte)
+	count       uint64
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: map[string]map[uint64]func(payload []byte){},
+	}
+}
+
+func (broker *MemoryBroker) Publish(channel string, payload []byte) error {
+	broker.mutex.RLock()
+	var handlers = make([]func(payload []byte), 0, len(broker.subscribers[channel]))
+	for _, handler := range broker.subscribers[channel] {
+		handlers = append(handlers, handler)
+	}
+	broker.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(payload)
+	}
+
+	return nil
+}
+
+func (broker *MemoryBroker) Subscribe(channel string, handler func(payload []byte)) (func(), error) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.count++
+	var id = broker.count
+	if broker.subscribers[channel] == nil {
+		broker.subscribers[channel] = map[uint64]func(payload []byte){}
+	}
+	broker.subscribers[channel][id] = handler
+
+	return func() {
+		broker.mutex.Lock()
+		defer broker.mutex.Unlock()
+		delete(broker.subscribers[channel], id)
+	}, nil
+}
+
+// PubSub 类 redis 的发布订阅连接，contracts.RedisConnection 即满足该接口
+type PubSub interface {
+	Publish(channel string, message interface{}) (int64, error)
+	SubscribeWithContext(ctx context.Context, channels []string, closure contracts.RedisSubscribeFunc) error
+}
+
+// RedisBroker 基于 redis 发布订阅的 broker
+type RedisBroker struct {
+	conn PubSub
+}
+
+func NewRedisBroker(conn PubSub) *RedisBroker {
+	return &RedisBroker{conn: conn}
+}
+
+func (broker *RedisBroker) Publish(channel string, payload []byte) error {
+	var _, err = broker.conn.Publish(channel, string(payload))
+	return err
+}
+
+// Subscribe 在后台订阅频道，取消订阅时结束 redis 的订阅和后台的 goroutine
+func (broker *RedisBroker) Subscribe(channel string, handler func(payload []byte)) (func(), error) {
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	go func() {
+		var err = broker.conn.SubscribeWithContext(ctx, []string{channel}, func(message, _ string) {
+			handler([]byte(message))
+		})
+		if err != nil && ctx.Err() == nil {
+			logs.WithError(err).WithField("channel", channel).Error("sse.RedisBroker.Subscribe: subscribe failed")
+		}
+	}()
+
+	return cancel, nil
+}
@@ -3,16 +3,133 @@ package sse
 import (
 	"github.com/goal-web/contracts"
 	"github.com/goal-web/supports/exceptions"
+	"sync"
+	"time"
 )
 
+const connectionKey = "goal.sse.connection"
+
+// Identifiable 可以绑定身份标识和元数据的连接
+type Identifiable interface {
+	contracts.SseConnection
+
+	// Identify 绑定连接的身份标识，例如用户 id，同一标识可以有多个连接
+	Identify(key string)
+
+	// Key 获取连接的身份标识
+	Key() string
+
+	// SetMeta 设置连接的元数据
+	SetMeta(key string, value interface{})
+
+	// Meta 获取连接的元数据
+	Meta(key string) interface{}
+
+	// Metadata 获取连接的所有元数据
+	Metadata() contracts.Fields
+
+	// ConnectedAt 获取连接建立的时间
+	ConnectedAt() time.Time
+
+	// RemoteAddr 获取客户端地址
+	RemoteAddr() string
+}
+
+// CurrentConnection 获取当前请求的 sse 连接，可以在 OnConnect 中为连接绑定身份和元数据
+func CurrentConnection(request contracts.HttpRequest) Identifiable {
+	if conn, ok := request.Get(connectionKey).(Identifiable); ok {
+		return conn
+	}
+	return nil
+}
+
+// identity 连接的身份和元数据，sse 连接和长轮询连接共用
+type identity struct {
+	connectedAt time.Time
+	remoteAddr  string
+
+	mutex    sync.RWMutex
+	key      string
+	metadata contracts.Fields
+	reindex  func()
+}
+
+func newIdentity(remoteAddr string) identity {
+	return identity{
+		connectedAt: time.Now(),
+		remoteAddr:  remoteAddr,
+		metadata:    contracts.Fields{},
+	}
+}
+
+func (info *identity) ConnectedAt() time.Time {
+	return info.connectedAt
+}
+
+func (info *identity) RemoteAddr() string {
+	return info.remoteAddr
+}
+
+// Identify 绑定身份标识，已经加入注册表的连接会同时更新注册表的索引
+func (info *identity) Identify(key string) {
+	info.mutex.Lock()
+	info.key = key
+	var reindex = info.reindex
+	info.mutex.Unlock()
+
+	if reindex != nil { // 不能持有连接的锁，注册表会在持有自己的锁时读取 Key
+		reindex()
+	}
+}
+
+func (info *identity) observe(reindex func()) {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	info.reindex = reindex
+}
+
+func (info *identity) Key() string {
+	info.mutex.RLock()
+	defer info.mutex.RUnlock()
+	return info.key
+}
+
+func (info *identity) SetMeta(key string, value interface{}) {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+	info.metadata[key] = value
+}
+
+func (info *identity) Meta(key string) interface{} {
+	info.mutex.RLock()
+	defer info.mutex.RUnlock()
+	return info.metadata[key]
+}
+
+func (info *identity) Metadata() contracts.Fields {
+	info.mutex.RLock()
+	defer info.mutex.RUnlock()
+	var metadata = make(contracts.Fields, len(info.metadata))
+	for key, value := range info.metadata {
+		metadata[key] = value
+	}
+	return metadata
+}
+
 type Connection struct {
+	identity
 	fd        uint64
 	msgPipe   chan interface{}
 	closePipe chan bool
 }
 
 func NewConnection(pipe chan interface{}, closePipe chan bool, fd uint64) contracts.SseConnection {
+	return newConnection(pipe, closePipe, fd, "")
+}
+
+func newConnection(pipe chan interface{}, closePipe chan bool, fd uint64, remoteAddr string) *Connection {
 	return &Connection{
+		identity:  newIdentity(remoteAddr),
 		fd:        fd,
 		msgPipe:   pipe,
 		closePipe: closePipe,
@@ -24,7 +141,10 @@ func (conn *Connection) Fd() uint64 {
 }
 
 func (conn *Connection) Close() error {
-	conn.closePipe <- true
+	select {
+	case conn.closePipe <- true:
+	default: // 已经在关闭中
+	}
 	return nil
 }
 
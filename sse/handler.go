@@ -9,7 +9,14 @@ import (
 
 func New(controller contracts.SseController) interface{} {
 	return func(request *http.Request, serializer contracts.Serializer, sse contracts.Sse) error {
-		var fd = sse.GetFd()
+		var (
+			fd          = sse.GetFd()
+			messageChan = make(chan interface{})
+			closeChan   = make(chan bool, 1)
+			conn        = newConnection(messageChan, closeChan, fd, request.RealIP())
+		)
+
+		request.Set(connectionKey, conn)
 		if err := controller.OnConnect(request, fd); err != nil {
 			logs.WithError(err).WithFields(request.Fields()).WithField("fd", fd).Debug("sse.New: OnConnect failed")
 			return err
@@ -21,18 +28,12 @@ func New(controller contracts.SseController) interface{} {
 		response.Header().Set("Connection", "keep-alive")
 		response.Header().Set("Access-Control-Allow-Origin", "*")
 
-		var (
-			messageChan = make(chan interface{})
-			closeChan   = make(chan bool)
-			conn        = NewConnection(messageChan, closeChan, fd)
-		)
 		sse.Add(conn)
 
 		defer func() {
+			_ = sse.Close(fd)
 			controller.OnClose(fd)
 			close(messageChan)
-			close(closeChan)
-			closeChan = nil
 			messageChan = nil
 		}()
 
@@ -53,7 +54,6 @@ func New(controller contracts.SseController) interface{} {
 
 			// connection is closed then defer will be executed
 			case <-request.Request().Context().Done():
-				_ = sse.Close(fd)
 				return nil
 			}
 		}
@@ -0,0 +1,329 @@
+package sse
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/http"
+	"github.com/goal-web/supports/logs"
+	stdhttp "net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ConnectionClosedErr = errors.New("connection is closed")
+	PollingRegistryErr  = errors.New("long polling requires a sse registry that implements Get")
+)
+
+type PollingConfig struct {
+	// Timeout 每次轮询在没有消息时最多挂起的时间，0 表示使用默认值
+	Timeout time.Duration
+
+	// IdleTimeout 超过该时间没有轮询则认为连接已断开，0 表示使用默认值
+	IdleTimeout time.Duration
+
+	// MaxBatch 每次轮询最多返回的消息数，0 表示使用默认值，负数表示不限制
+	MaxBatch int
+
+	// MaxQueue 每个连接最多缓存的消息数，超出时丢弃最早的消息，0 表示使用默认值，负数表示不限制
+	MaxQueue int
+}
+
+func DefaultPollingConfig() PollingConfig {
+	return PollingConfig{
+		Timeout:     25 * time.Second,
+		IdleTimeout: 60 * time.Second,
+		MaxBatch:    100,
+		MaxQueue:    1000,
+	}
+}
+
+// withDefaults 逐个字段使用默认值填充未设置的配置，只设置了部分字段的配置也可以直接使用
+func (config PollingConfig) withDefaults() PollingConfig {
+	var defaults = DefaultPollingConfig()
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = defaults.IdleTimeout
+	}
+	if config.MaxBatch == 0 {
+		config.MaxBatch = defaults.MaxBatch
+	}
+	if config.MaxQueue == 0 {
+		config.MaxQueue = defaults.MaxQueue
+	}
+	return config
+}
+
+// PollResult 每次轮询的响应，客户端下次轮询时需要带上 fd、建立连接时返回的 token 和 cursor
+type PollResult struct {
+	Fd       uint64   `json:"fd"`
+	Token    string   `json:"token,omitempty"`
+	Cursor   uint64   `json:"cursor"`
+	Messages []string `json:"messages"`
+	Closed   bool     `json:"closed,omitempty"`
+}
+
+type polledMessage struct {
+	seq  uint64
+	data string
+}
+
+// PollingConnection 长轮询连接，和 sse 连接共用 Sse 的注册表，Sse.Send 对两种连接透明
+type PollingConnection struct {
+	identity
+	fd         uint64
+	token      string
+	serializer contracts.Serializer
+	config     PollingConfig
+
+	queueMutex sync.Mutex
+	queue      []polledMessage
+	seq        uint64
+	notify     chan struct{}
+	closed     bool
+	polling    int // 挂起中的轮询数，大于 0 时不计算空闲时间
+	idleTimer  *time.Timer
+	onClose    func()
+}
+
+func newPollingConnection(fd uint64, token, remoteAddr string, serializer contracts.Serializer, config PollingConfig) *PollingConnection {
+	return &PollingConnection{
+		identity:   newIdentity(remoteAddr),
+		fd:         fd,
+		token:      token,
+		serializer: serializer,
+		config:     config,
+		queue:      make([]polledMessage, 0),
+		notify:     make(chan struct{}, 1),
+	}
+}
+
+func (conn *PollingConnection) Fd() uint64 {
+	return conn.fd
+}
+
+// authorize 校验轮询请求带上的 token，fd 是递增的，只凭 fd 可以读取别人的消息
+func (conn *PollingConnection) authorize(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(conn.token)) == 1
+}
+
+func (conn *PollingConnection) Send(message interface{}) error {
+	conn.queueMutex.Lock()
+	defer conn.queueMutex.Unlock()
+
+	if conn.closed {
+		return ConnectionClosedErr
+	}
+
+	conn.seq++
+	conn.queue = append(conn.queue, polledMessage{seq: conn.seq, data: string(handleMessage(message, conn.serializer))})
+	if conn.config.MaxQueue > 0 && len(conn.queue) > conn.config.MaxQueue {
+		conn.queue = conn.queue[len(conn.queue)-conn.config.MaxQueue:]
+	}
+	conn.signal()
+
+	return nil
+}
+
+func (conn *PollingConnection) Close() error {
+	conn.queueMutex.Lock()
+	if conn.closed {
+		conn.queueMutex.Unlock()
+		return nil
+	}
+	conn.closed = true
+	if conn.idleTimer != nil {
+		conn.idleTimer.Stop()
+	}
+	conn.signal()
+	var onClose = conn.onClose
+	conn.queueMutex.Unlock()
+
+	if onClose != nil {
+		onClose()
+	}
+	return nil
+}
+
+// Poll 确认 cursor 之前的消息并等待新消息，没有消息时最多等待 Timeout
+func (conn *PollingConnection) Poll(ctx context.Context, cursor uint64) PollResult {
+	conn.ack(cursor)
+
+	var timeout = time.NewTimer(conn.config.Timeout)
+	defer timeout.Stop()
+
+	for {
+		if result, ready := conn.take(cursor); ready {
+			return result
+		}
+
+		select {
+		case <-conn.notify:
+		case <-timeout.C:
+			result, _ := conn.take(cursor)
+			return result
+		case <-ctx.Done():
+			result, _ := conn.take(cursor)
+			return result
+		}
+	}
+}
+
+// touch 刷新空闲计时，超时后执行 expire
+func (conn *PollingConnection) touch(expire func()) {
+	conn.queueMutex.Lock()
+	defer conn.queueMutex.Unlock()
+	conn.resetIdle(expire)
+}
+
+// pause 轮询挂起期间停止空闲计时，长时间的轮询不会被当成空闲连接关闭
+func (conn *PollingConnection) pause() {
+	conn.queueMutex.Lock()
+	defer conn.queueMutex.Unlock()
+	conn.polling++
+	if conn.idleTimer != nil {
+		conn.idleTimer.Stop()
+	}
+}
+
+// resume 轮询结束后重新开始空闲计时
+func (conn *PollingConnection) resume(expire func()) {
+	conn.queueMutex.Lock()
+	defer conn.queueMutex.Unlock()
+	conn.polling--
+	conn.resetIdle(expire)
+}
+
+// resetIdle 重新开始空闲计时，需要持有 queueMutex
+func (conn *PollingConnection) resetIdle(expire func()) {
+	if conn.closed || conn.polling > 0 {
+		return
+	}
+	if conn.idleTimer == nil {
+		conn.idleTimer = time.AfterFunc(conn.config.IdleTimeout, expire)
+	} else {
+		conn.idleTimer.Reset(conn.config.IdleTimeout)
+	}
+}
+
+// ack 丢弃客户端已经收到的消息
+func (conn *PollingConnection) ack(cursor uint64) {
+	conn.queueMutex.Lock()
+	defer conn.queueMutex.Unlock()
+	var index = 0
+	for index < len(conn.queue) && conn.queue[index].seq <= cursor {
+		index++
+	}
+	conn.queue = conn.queue[index:]
+}
+
+// take 取出 cursor 之后的一批消息，有消息或者连接已关闭时 ready 为 true
+func (conn *PollingConnection) take(cursor uint64) (result PollResult, ready bool) {
+	conn.queueMutex.Lock()
+	defer conn.queueMutex.Unlock()
+
+	result = PollResult{Fd: conn.fd, Cursor: cursor, Messages: make([]string, 0), Closed: conn.closed}
+	for _, msg := range conn.queue {
+		if msg.seq <= cursor {
+			continue
+		}
+		if conn.config.MaxBatch > 0 && len(result.Messages) >= conn.config.MaxBatch {
+			break
+		}
+		result.Messages = append(result.Messages, msg.data)
+		result.Cursor = msg.seq
+	}
+
+	return result, len(result.Messages) > 0 || conn.closed
+}
+
+func (conn *PollingConnection) signal() {
+	select {
+	case conn.notify <- struct{}{}:
+	default:
+	}
+}
+
+// registry 可以按 fd 获取连接的注册表，Sse 满足该接口
+type registry interface {
+	Get(fd uint64) contracts.SseConnection
+}
+
+// NewPolling 创建长轮询的路由处理器，作为代理缓冲 text/event-stream 时的降级方案
+// 首次请求不带 fd 时建立连接，之后客户端带上 fd、token 和上次返回的 cursor 持续轮询
+func NewPolling(controller contracts.SseController, config ...PollingConfig) interface{} {
+	var conf = DefaultPollingConfig()
+	if len(config) > 0 {
+		conf = config[0].withDefaults()
+	}
+
+	return func(request *http.Request, serializer contracts.Serializer, sse contracts.Sse) error {
+		var fdParam = request.QueryParam("fd")
+		if fdParam == "" {
+			return connectPolling(request, serializer, sse, controller, conf)
+		}
+
+		var fd, err = strconv.ParseUint(fdParam, 10, 64)
+		if err != nil {
+			return request.JSON(stdhttp.StatusBadRequest, contracts.Fields{"error": ConnectionIdErr.Error()})
+		}
+		var registry, isRegistry = sse.(registry)
+		if !isRegistry {
+			return PollingRegistryErr
+		}
+		var conn, isPolling = registry.Get(fd).(*PollingConnection)
+		if !isPolling || !conn.authorize(request.QueryParam("token")) { // token 不匹配时与连接不存在的响应相同
+			return request.JSON(stdhttp.StatusGone, contracts.Fields{"error": ConnectionDontExistsErr.Error()})
+		}
+
+		var (
+			cursor, _ = strconv.ParseUint(request.QueryParam("cursor"), 10, 64)
+			expire    = func() { _ = sse.Close(fd) }
+		)
+		conn.pause()
+		defer conn.resume(expire)
+
+		return request.JSON(stdhttp.StatusOK, conn.Poll(request.Request().Context(), cursor))
+	}
+}
+
+func connectPolling(request *http.Request, serializer contracts.Serializer, sse contracts.Sse, controller contracts.SseController, config PollingConfig) error {
+	var token, err = newPollingToken()
+	if err != nil {
+		return err
+	}
+	var (
+		fd   = sse.GetFd()
+		conn = newPollingConnection(fd, token, request.RealIP(), serializer, config)
+	)
+
+	request.Set(connectionKey, conn)
+	if err := controller.OnConnect(request, fd); err != nil {
+		logs.WithError(err).WithFields(request.Fields()).WithField("fd", fd).Debug("sse.NewPolling: OnConnect failed")
+		return err
+	}
+
+	conn.onClose = func() {
+		controller.OnClose(fd)
+	}
+	sse.Add(conn)
+	conn.touch(func() { _ = sse.Close(fd) })
+
+	return request.JSON(stdhttp.StatusOK, PollResult{Fd: fd, Token: token, Messages: make([]string, 0)})
+}
+
+// newPollingToken 生成连接的随机 token，之后的每次轮询都需要带上
+func newPollingToken() (string, error) {
+	var token = make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,100 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/http"
+	"github.com/labstack/echo/v4"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// poll 调用长轮询处理器，返回状态码和响应
+func poll(handler interface{}, sse contracts.Sse, query string) (int, PollResult, error) {
+	var (
+		recorder = httptest.NewRecorder()
+		request  = http.NewRequest(echo.New().NewContext(httptest.NewRequest("GET", "/poll?"+query, nil), recorder)).(*http.Request)
+		result   PollResult
+	)
+	var err = handler.(func(*http.Request, contracts.Serializer, contracts.Sse) error)(request, testSerializer{}, sse)
+	_ = json.Unmarshal(recorder.Body.Bytes(), &result)
+	return recorder.Code, result, err
+}
+
+func TestPollingConfigDefaults(t *testing.T) {
+	var (
+		defaults = DefaultPollingConfig()
+		config   = PollingConfig{Timeout: time.Second}.withDefaults()
+	)
+	if config.Timeout != time.Second || config.IdleTimeout != defaults.IdleTimeout ||
+		config.MaxBatch != defaults.MaxBatch || config.MaxQueue != defaults.MaxQueue {
+		t.Fatalf("unexpected defaults %+v", config)
+	}
+	if config = (PollingConfig{MaxBatch: -1, MaxQueue: -1}).withDefaults(); config.MaxBatch != -1 || config.MaxQueue != -1 {
+		t.Fatalf("negative limits must stay unbounded, got %+v", config)
+	}
+}
+
+func TestPollingQueueAndBatchLimits(t *testing.T) {
+	var conn = newPollingConnection(1, "token", "127.0.0.1", testSerializer{}, PollingConfig{MaxBatch: 2, MaxQueue: 3}.withDefaults())
+	for i := 1; i <= 5; i++ {
+		_ = conn.Send(fmt.Sprint(i))
+	}
+
+	var result, _ = conn.take(0)
+	if len(result.Messages) != 2 || result.Messages[0] != "3" || result.Cursor != 4 {
+		t.Fatalf("expected the oldest messages to be dropped and the batch limited, got %+v", result)
+	}
+	if result, _ = conn.take(result.Cursor); len(result.Messages) != 1 || result.Messages[0] != "5" {
+		t.Fatalf("unexpected second batch %+v", result)
+	}
+}
+
+func TestPollingIdleTimerPausedWhilePolling(t *testing.T) {
+	var (
+		sse     = newTestSse("node")
+		handler = NewPolling(&reentrantController{sse: sse, closed: make(chan uint64, 1)}, PollingConfig{
+			Timeout:     150 * time.Millisecond,
+			IdleTimeout: 50 * time.Millisecond,
+		})
+	)
+	var _, connected, err = poll(handler, sse, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var code, result, _ = poll(handler, sse, fmt.Sprintf("fd=%d&token=%s", connected.Fd, connected.Token))
+	if code != stdhttp.StatusOK || result.Closed || sse.Count() != 1 {
+		t.Fatalf("a poll longer than IdleTimeout must not expire the connection, got %d %+v", code, result)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if sse.Count() != 0 {
+		t.Fatal("expected the connection to expire once no poll is outstanding")
+	}
+}
+
+func TestPollingRejectsUnknownConnection(t *testing.T) {
+	var (
+		sse     = newTestSse("node")
+		handler = NewPolling(&reentrantController{sse: sse, closed: make(chan uint64, 1)})
+	)
+	var _, connected, _ = poll(handler, sse, "")
+
+	for _, query := range []string{
+		fmt.Sprintf("fd=%d&token=wrong", connected.Fd),
+		fmt.Sprintf("fd=%d&token=%s", connected.Fd+1, connected.Token),
+	} {
+		if code, _, _ := poll(handler, sse, query); code != stdhttp.StatusGone {
+			t.Errorf("%s: expected 410, got %d", query, code)
+		}
+	}
+
+	// 没有实现 Get 的 Sse 无法按 fd 查找连接
+	if _, _, err := poll(handler, struct{ contracts.Sse }{sse}, fmt.Sprintf("fd=%d&token=%s", connected.Fd, connected.Token)); err != PollingRegistryErr {
+		t.Fatalf("expected PollingRegistryErr, got %v", err)
+	}
+}
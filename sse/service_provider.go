@@ -1,21 +1,42 @@
 package sse
 
 import (
+	"fmt"
 	"github.com/goal-web/contracts"
+	"github.com/goal-web/http"
+	"os"
 	"sync"
 )
 
 type ServiceProvider struct {
+	// Node 当前节点标识，用于跨节点投递消息，默认为 hostname-pid
+	Node string
+
+	// BrokerProvider 返回 Broker 的函数，参数由容器注入，为空时只在当前节点投递消息
+	BrokerProvider interface{}
 }
 
+// Register 注册 sse 实例，创建时订阅 broker，http 服务关闭时取消订阅并关闭所有连接
 func (s ServiceProvider) Register(application contracts.Application) {
-	application.Singleton("sse", func() contracts.Sse {
-		return &Sse{
+	application.Singleton("sse", func(serializer contracts.Serializer, events contracts.EventDispatcher) contracts.Sse {
+		var sse = &Sse{
 			fdMutex:     sync.Mutex{},
-			connMutex:   sync.Mutex{},
+			connMutex:   sync.RWMutex{},
 			connections: map[uint64]contracts.SseConnection{},
 			count:       0,
+			node:        s.node(),
+			serializer:  serializer,
+			channels:    map[string]map[uint64]bool{},
+			users:       map[string]map[uint64]bool{},
+			keys:        map[uint64]string{},
+		}
+		if s.BrokerProvider != nil {
+			if err := sse.UseBroker(s.broker(application)); err != nil {
+				panic(err)
+			}
 		}
+		events.Register((&http.ServeClosed{}).Event(), closeListener{sse: sse})
+		return sse
 	})
 }
 
@@ -25,3 +46,32 @@ func (s ServiceProvider) Start() error {
 
 func (s ServiceProvider) Stop() {
 }
+
+// broker 通过容器调用 BrokerProvider，没有返回 Broker 时 panic
+func (s ServiceProvider) broker(application contracts.Application) Broker {
+	var results = application.Call(s.BrokerProvider)
+	if len(results) > 0 {
+		if broker, isBroker := results[0].(Broker); isBroker {
+			return broker
+		}
+	}
+	panic(BrokerTypeErr)
+}
+
+func (s ServiceProvider) node() string {
+	if s.Node != "" {
+		return s.Node
+	}
+	var hostname, _ = os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// closeListener http 服务关闭后取消订阅 broker 并关闭所有连接
+type closeListener struct {
+	sse *Sse
+}
+
+func (listener closeListener) Handle(contracts.Event) {
+	listener.sse.Unsubscribe()
+	listener.sse.CloseAll()
+}
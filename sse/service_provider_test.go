@@ -0,0 +1,117 @@
+package sse
+
+import (
+	"encoding/json"
+	"github.com/goal-web/container"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/http"
+	"github.com/goal-web/supports/utils"
+	"reflect"
+	"testing"
+)
+
+// testApplication 只提供容器的应用
+type testApplication struct {
+	contracts.Container
+}
+
+func (testApplication) GetExceptionHandler() contracts.ExceptionHandler { return nil }
+func (testApplication) IsProduction() bool                              { return false }
+func (testApplication) Debug() bool                                     { return false }
+func (testApplication) Environment() string                             { return "testing" }
+func (testApplication) RegisterServices(...contracts.ServiceProvider)   {}
+func (testApplication) Start() map[string]error                         { return nil }
+func (testApplication) Stop()                                           {}
+
+type testSerializer struct{}
+
+func (testSerializer) Serialize(value interface{}) string {
+	var data, _ = json.Marshal(value)
+	return string(data)
+}
+
+func (testSerializer) Unserialize(data string, value interface{}) error {
+	return json.Unmarshal([]byte(data), value)
+}
+
+// testEvents 同步分发事件的调度器
+type testEvents map[string][]contracts.EventListener
+
+func (events testEvents) Register(name string, listener contracts.EventListener) {
+	events[name] = append(events[name], listener)
+}
+
+func (events testEvents) Dispatch(event contracts.Event) {
+	for _, listener := range events[event.Event()] {
+		listener.Handle(event)
+	}
+}
+
+func newTestApplication(provider ServiceProvider) (testApplication, testEvents) {
+	var (
+		app    = testApplication{container.New()}
+		events = testEvents{}
+	)
+	app.Instance(utils.GetTypeKey(reflect.TypeOf((*contracts.Serializer)(nil)).Elem()), testSerializer{})
+	app.Instance(utils.GetTypeKey(reflect.TypeOf((*contracts.EventDispatcher)(nil)).Elem()), events)
+	provider.Register(app)
+	return app, events
+}
+
+func TestServiceProvidersWithSameNode(t *testing.T) {
+	var (
+		brokers      = []*MemoryBroker{NewMemoryBroker(), NewMemoryBroker()}
+		instances    []*Sse
+		dispatchers  []testEvents
+		applications []testApplication
+	)
+	for _, broker := range brokers {
+		var broker = broker
+		var app, events = newTestApplication(ServiceProvider{BrokerProvider: func() Broker { return broker }})
+		applications = append(applications, app)
+		dispatchers = append(dispatchers, events)
+		instances = append(instances, app.Get("sse").(*Sse))
+	}
+	if instances[0] == instances[1] || instances[0].Node() != instances[1].Node() {
+		t.Fatal("expected two instances with the same default node")
+	}
+	for index, sse := range instances {
+		if sse.currentBroker() != brokers[index] {
+			t.Fatalf("application %d uses the broker of another application", index)
+		}
+	}
+
+	var conn, _ = newTestConnection(instances[0], "alice")
+	instances[0].Add(conn)
+	dispatchers[1].Dispatch(&http.ServeClosed{})
+	if instances[0].Count() != 1 || subscriptions(brokers[0]) == 0 || subscriptions(brokers[1]) != 0 {
+		t.Fatal("ServeClosed of one application must only stop its own sse")
+	}
+	dispatchers[0].Dispatch(&http.ServeClosed{})
+	if instances[0].Count() != 0 || subscriptions(brokers[0]) != 0 {
+		t.Fatal("expected ServeClosed to unsubscribe and close all connections")
+	}
+}
+
+func subscriptions(broker *MemoryBroker) (count int) {
+	broker.mutex.RLock()
+	defer broker.mutex.RUnlock()
+	for _, handlers := range broker.subscribers {
+		count += len(handlers)
+	}
+	return count
+}
+
+func TestBrokerProviderWithoutBroker(t *testing.T) {
+	for _, provider := range []interface{}{func() {}, func() string { return "redis" }} {
+		var app, _ = newTestApplication(ServiceProvider{BrokerProvider: provider})
+		func() {
+			defer func() {
+				if err := recover(); err != BrokerTypeErr {
+					t.Fatalf("expected BrokerTypeErr, got %v", err)
+				}
+			}()
+			app.Get("sse")
+		}()
+	}
+}
@@ -1,26 +1,101 @@
 package sse
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/logs"
+	"strconv"
+	"strings"
 	"sync"
 )
 
 var (
 	ConnectionDontExistsErr = errors.New("connection does not exist")
+	ConnectionIdErr         = errors.New("invalid connection id")
+	BrokerTypeErr           = errors.New("BrokerProvider must return a sse.Broker")
 )
 
+const (
+	nodeChannelPrefix = "goal.sse.node."
+	broadcastChannel  = "goal.sse.broadcast"
+)
+
+// envelope 通过 broker 传递的消息
+type envelope struct {
+	Node    string `json:"node"`
+	Fd      uint64 `json:"fd,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	User    string `json:"user,omitempty"`
+	Close   bool   `json:"close,omitempty"`
+	Payload string `json:"payload"`
+}
+
+// identified 绑定了身份标识的连接
+type identified interface {
+	Key() string
+}
+
+// observable 加入注册表之后还可以修改身份标识的连接，修改后通知注册表更新索引
+type observable interface {
+	observe(reindex func())
+}
+
 type Sse struct {
 	fdMutex     sync.Mutex
-	connMutex   sync.Mutex
+	connMutex   sync.RWMutex
 	connections map[uint64]contracts.SseConnection
 	count       uint64
+
+	node          string
+	serializer    contracts.Serializer
+	brokerMutex   sync.RWMutex
+	broker        Broker
+	unsubscribers []func()
+	channels      map[string]map[uint64]bool
+	users         map[string]map[uint64]bool
+	keys          map[uint64]string // 连接当前在 users 中的身份标识
 }
 
 func (sse *Sse) Add(connect contracts.SseConnection) {
 	sse.connMutex.Lock()
 	defer sse.connMutex.Unlock()
-	sse.connections[connect.Fd()] = connect
+	var fd = connect.Fd()
+	sse.connections[fd] = connect
+	if conn, ok := connect.(observable); ok {
+		conn.observe(func() { sse.reindex(fd) })
+	}
+	if conn, ok := connect.(identified); ok {
+		sse.index(fd, conn.Key())
+	}
+}
+
+// reindex 连接加入注册表之后调用了 Identify，按新的身份标识更新索引
+func (sse *Sse) reindex(fd uint64) {
+	sse.connMutex.Lock()
+	defer sse.connMutex.Unlock()
+	if conn, ok := sse.connections[fd].(identified); ok {
+		sse.index(fd, conn.Key())
+	}
+}
+
+// index 把连接从原来的身份标识移到 key 下，key 为空时只移除，需要持有 connMutex
+func (sse *Sse) index(fd uint64, key string) {
+	if previous, exists := sse.keys[fd]; exists {
+		delete(sse.users[previous], fd)
+		if len(sse.users[previous]) == 0 {
+			delete(sse.users, previous)
+		}
+		delete(sse.keys, fd)
+	}
+	if key != "" {
+		if sse.users[key] == nil {
+			sse.users[key] = map[uint64]bool{}
+		}
+		sse.users[key][fd] = true
+		sse.keys[fd] = key
+	}
 }
 
 func (sse *Sse) GetFd() uint64 {
@@ -31,23 +106,325 @@ func (sse *Sse) GetFd() uint64 {
 	return fd
 }
 
+// Close 从注册表移除连接后再关闭连接，关闭连接时会执行 OnClose，不能持有注册表的锁
 func (sse *Sse) Close(fd uint64) error {
-	var conn, exists = sse.connections[fd]
-	if exists {
-		sse.connMutex.Lock()
-		defer sse.connMutex.Unlock()
-		delete(sse.connections, fd)
-		return conn.Close()
+	var conn = sse.remove(fd)
+	if conn == nil {
+		return ConnectionDontExistsErr
 	}
 
-	return ConnectionDontExistsErr
+	return conn.Close()
 }
 
-func (sse *Sse) Send(fd uint64, message interface{}) error {
+// remove 从注册表和所有频道中移除连接，返回被移除的连接，不存在时返回 nil
+func (sse *Sse) remove(fd uint64) contracts.SseConnection {
+	sse.connMutex.Lock()
+	defer sse.connMutex.Unlock()
+
 	var conn, exists = sse.connections[fd]
-	if exists {
+	if !exists {
+		return nil
+	}
+	delete(sse.connections, fd)
+	for _, fds := range sse.channels {
+		delete(fds, fd)
+	}
+	sse.index(fd, "")
+	return conn
+}
+
+func (sse *Sse) Send(fd uint64, message interface{}) error {
+	if conn := sse.Get(fd); conn != nil {
 		return conn.Send(message)
 	}
 
 	return ConnectionDontExistsErr
 }
+
+// Get 获取当前节点上指定 fd 的连接，不存在时返回 nil
+func (sse *Sse) Get(fd uint64) contracts.SseConnection {
+	sse.connMutex.RLock()
+	defer sse.connMutex.RUnlock()
+	return sse.connections[fd]
+}
+
+// Count 获取当前节点上的连接数
+func (sse *Sse) Count() int {
+	sse.connMutex.RLock()
+	defer sse.connMutex.RUnlock()
+	return len(sse.connections)
+}
+
+// Range 遍历当前节点上的连接，callback 返回 false 时停止遍历
+// 遍历的是连接的快照，callback 中可以安全地发送消息或者关闭连接
+func (sse *Sse) Range(callback func(conn contracts.SseConnection) bool) {
+	for _, conn := range sse.snapshot() {
+		if !callback(conn) {
+			return
+		}
+	}
+}
+
+// CloseAll 关闭当前节点上的所有连接，通常在服务关闭时调用
+func (sse *Sse) CloseAll() {
+	for _, conn := range sse.snapshot() {
+		_ = sse.Close(conn.Fd())
+	}
+}
+
+func (sse *Sse) snapshot() []contracts.SseConnection {
+	sse.connMutex.RLock()
+	defer sse.connMutex.RUnlock()
+	var connections = make([]contracts.SseConnection, 0, len(sse.connections))
+	for _, conn := range sse.connections {
+		connections = append(connections, conn)
+	}
+	return connections
+}
+
+// Node 获取当前节点标识
+func (sse *Sse) Node() string {
+	return sse.node
+}
+
+// Id 获取带节点标识的连接 id，可以在任意节点通过 SendTo 发送消息
+func (sse *Sse) Id(fd uint64) string {
+	return fmt.Sprintf("%s:%d", sse.node, fd)
+}
+
+// ParseId 解析带节点标识的连接 id
+func ParseId(id string) (node string, fd uint64, err error) {
+	var index = strings.LastIndex(id, ":")
+	if index <= 0 {
+		return "", 0, ConnectionIdErr
+	}
+	if fd, err = strconv.ParseUint(id[index+1:], 10, 64); err != nil {
+		return "", 0, ConnectionIdErr
+	}
+	return id[:index], fd, nil
+}
+
+// SendTo 发送消息给带节点标识的连接，连接不在当前节点时通过 broker 转发
+func (sse *Sse) SendTo(id string, message interface{}) error {
+	var node, fd, err = ParseId(id)
+	if err != nil {
+		return err
+	}
+	if node == sse.node {
+		return sse.Send(fd, message)
+	}
+	var broker = sse.currentBroker()
+	if broker == nil {
+		return ConnectionDontExistsErr
+	}
+
+	return sse.publish(broker, nodeChannelPrefix+node, envelope{Fd: fd, Payload: sse.serialize(message)})
+}
+
+// Join 把连接加入频道
+func (sse *Sse) Join(fd uint64, channel string) error {
+	sse.connMutex.Lock()
+	defer sse.connMutex.Unlock()
+	if _, exists := sse.connections[fd]; !exists {
+		return ConnectionDontExistsErr
+	}
+	if sse.channels[channel] == nil {
+		sse.channels[channel] = map[uint64]bool{}
+	}
+	sse.channels[channel][fd] = true
+	return nil
+}
+
+// Leave 把连接移出频道
+func (sse *Sse) Leave(fd uint64, channel string) {
+	sse.connMutex.Lock()
+	defer sse.connMutex.Unlock()
+	delete(sse.channels[channel], fd)
+	if len(sse.channels[channel]) == 0 {
+		delete(sse.channels, channel)
+	}
+}
+
+// Broadcast 广播消息给所有节点上加入了该频道的连接
+func (sse *Sse) Broadcast(channel string, message interface{}) error {
+	var (
+		payload = sse.serialize(message)
+		broker  = sse.currentBroker()
+	)
+	if broker == nil {
+		sse.broadcastLocal(channel, payload)
+		return nil
+	}
+
+	return sse.publish(broker, broadcastChannel, envelope{Channel: channel, Payload: payload})
+}
+
+// ConnectionsOf 获取当前节点上指定身份标识的所有连接
+func (sse *Sse) ConnectionsOf(key string) []contracts.SseConnection {
+	sse.connMutex.RLock()
+	defer sse.connMutex.RUnlock()
+	var connections = make([]contracts.SseConnection, 0, len(sse.users[key]))
+	for fd := range sse.users[key] {
+		if conn, exists := sse.connections[fd]; exists {
+			connections = append(connections, conn)
+		}
+	}
+	return connections
+}
+
+// SendToUser 发送消息给所有节点上指定身份标识的连接，例如同一用户打开的多个标签页
+func (sse *Sse) SendToUser(key string, message interface{}) error {
+	var (
+		payload = sse.serialize(message)
+		broker  = sse.currentBroker()
+	)
+	if broker == nil {
+		if !sse.sendToUserLocal(key, payload) {
+			return ConnectionDontExistsErr
+		}
+		return nil
+	}
+
+	return sse.publish(broker, broadcastChannel, envelope{User: key, Payload: payload})
+}
+
+// CloseUser 关闭所有节点上指定身份标识的连接
+func (sse *Sse) CloseUser(key string) error {
+	var broker = sse.currentBroker()
+	if broker == nil {
+		if !sse.closeUserLocal(key) {
+			return ConnectionDontExistsErr
+		}
+		return nil
+	}
+
+	return sse.publish(broker, broadcastChannel, envelope{User: key, Close: true})
+}
+
+// UseBroker 设置 broker 并订阅当前节点和广播的频道
+func (sse *Sse) UseBroker(broker Broker) error {
+	sse.Unsubscribe()
+
+	var (
+		unsubscribers []func()
+		handlers      = map[string]func(payload []byte){
+			nodeChannelPrefix + sse.node: sse.handleNodeMessage,
+			broadcastChannel:             sse.handleBroadcastMessage,
+		}
+	)
+	for channel, handler := range handlers {
+		var unsubscribe, err = broker.Subscribe(channel, handler)
+		if err != nil {
+			for _, unsubscribe = range unsubscribers {
+				unsubscribe()
+			}
+			return err
+		}
+		unsubscribers = append(unsubscribers, unsubscribe)
+	}
+
+	sse.brokerMutex.Lock()
+	var previous = sse.unsubscribers
+	sse.broker = broker
+	sse.unsubscribers = unsubscribers
+	sse.brokerMutex.Unlock()
+
+	for _, unsubscribe := range previous { // 并发调用 UseBroker 时取消被替换的订阅
+		unsubscribe()
+	}
+
+	return nil
+}
+
+// Unsubscribe 取消 broker 的订阅
+func (sse *Sse) Unsubscribe() {
+	sse.brokerMutex.Lock()
+	var unsubscribers = sse.unsubscribers
+	sse.unsubscribers = nil
+	sse.brokerMutex.Unlock()
+
+	for _, unsubscribe := range unsubscribers {
+		unsubscribe()
+	}
+}
+
+func (sse *Sse) currentBroker() Broker {
+	sse.brokerMutex.RLock()
+	defer sse.brokerMutex.RUnlock()
+	return sse.broker
+}
+
+func (sse *Sse) publish(broker Broker, channel string, msg envelope) error {
+	msg.Node = sse.node
+	var payload, err = json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return broker.Publish(channel, payload)
+}
+
+func (sse *Sse) handleNodeMessage(payload []byte) {
+	var msg envelope
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		logs.WithError(err).Error("sse.handleNodeMessage: unmarshal failed")
+		return
+	}
+	if err := sse.Send(msg.Fd, msg.Payload); err != nil {
+		logs.WithError(err).WithField("fd", msg.Fd).WithField("from", msg.Node).Debug("sse.handleNodeMessage: send failed")
+	}
+}
+
+func (sse *Sse) handleBroadcastMessage(payload []byte) {
+	var msg envelope
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		logs.WithError(err).Error("sse.handleBroadcastMessage: unmarshal failed")
+		return
+	}
+	switch {
+	case msg.User != "" && msg.Close:
+		sse.closeUserLocal(msg.User)
+	case msg.User != "":
+		sse.sendToUserLocal(msg.User, msg.Payload)
+	default:
+		sse.broadcastLocal(msg.Channel, msg.Payload)
+	}
+}
+
+func (sse *Sse) broadcastLocal(channel string, payload string) {
+	sse.connMutex.RLock()
+	var connections = make([]contracts.SseConnection, 0, len(sse.channels[channel]))
+	for fd := range sse.channels[channel] {
+		if conn, exists := sse.connections[fd]; exists {
+			connections = append(connections, conn)
+		}
+	}
+	sse.connMutex.RUnlock()
+
+	for _, conn := range connections {
+		if err := conn.Send(payload); err != nil {
+			logs.WithError(err).WithField("fd", conn.Fd()).WithField("channel", channel).Debug("sse.broadcastLocal: send failed")
+		}
+	}
+}
+
+func (sse *Sse) sendToUserLocal(key string, payload string) bool {
+	var connections = sse.ConnectionsOf(key)
+	for _, conn := range connections {
+		if err := conn.Send(payload); err != nil {
+			logs.WithError(err).WithField("fd", conn.Fd()).WithField("user", key).Debug("sse.sendToUserLocal: send failed")
+		}
+	}
+	return len(connections) > 0
+}
+
+func (sse *Sse) closeUserLocal(key string) bool {
+	var connections = sse.ConnectionsOf(key)
+	for _, conn := range connections {
+		_ = sse.Close(conn.Fd())
+	}
+	return len(connections) > 0
+}
+
+func (sse *Sse) serialize(message interface{}) string {
+	return string(handleMessage(message, sse.serializer))
+}
@@ -0,0 +1,298 @@
+package sse
+
+import (
+	"fmt"
+	"github.com/goal-web/contracts"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestSse(node string) *Sse {
+	return &Sse{
+		connections: map[uint64]contracts.SseConnection{},
+		node:        node,
+		channels:    map[string]map[uint64]bool{},
+		users:       map[string]map[uint64]bool{},
+		keys:        map[uint64]string{},
+	}
+}
+
+// newTestConnection 创建一个持续读取消息的连接，返回收到的消息数
+func newTestConnection(sse *Sse, user string) (*Connection, func() int) {
+	var (
+		pipe     = make(chan interface{})
+		conn     = newConnection(pipe, make(chan bool, 1), sse.GetFd(), "127.0.0.1")
+		mutex    sync.Mutex
+		received int
+	)
+	conn.Identify(user)
+	go func() {
+		for range pipe {
+			mutex.Lock()
+			received++
+			mutex.Unlock()
+		}
+	}()
+	return conn, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return received
+	}
+}
+
+func TestRegistryConcurrency(t *testing.T) {
+	var (
+		sse   = newTestSse("node")
+		group sync.WaitGroup
+	)
+
+	for worker := 0; worker < 8; worker++ {
+		group.Add(1)
+		go func(worker int) {
+			defer group.Done()
+			var user = fmt.Sprintf("user-%d", worker%3)
+			for i := 0; i < 200; i++ {
+				var conn, _ = newTestConnection(sse, user)
+				sse.Add(conn)
+				_ = sse.Join(conn.Fd(), "room")
+				_ = sse.Send(conn.Fd(), "direct")
+				_ = sse.Broadcast("room", "broadcast")
+				_ = sse.SendToUser(user, "user")
+				sse.Range(func(conn contracts.SseConnection) bool {
+					return conn.Fd()%2 == 0
+				})
+				_ = sse.ConnectionsOf(user)
+				_ = sse.Count()
+				if i%3 == 0 {
+					sse.Leave(conn.Fd(), "room")
+				}
+				if i%2 == 0 {
+					_ = sse.Close(conn.Fd())
+				}
+			}
+		}(worker)
+	}
+	group.Add(1)
+	go func() {
+		defer group.Done()
+		for i := 0; i < 20; i++ {
+			sse.CloseAll()
+		}
+	}()
+	group.Wait()
+
+	sse.CloseAll()
+	if count := sse.Count(); count != 0 {
+		t.Fatalf("expected no connections after CloseAll, got %d", count)
+	}
+	if len(sse.users) != 0 {
+		t.Fatalf("expected no users after CloseAll, got %d", len(sse.users))
+	}
+	for channel, fds := range sse.channels {
+		if len(fds) != 0 {
+			t.Fatalf("expected channel %s to be empty, got %d", channel, len(fds))
+		}
+	}
+}
+
+func TestSendToUserAcrossNodes(t *testing.T) {
+	var (
+		broker = NewMemoryBroker()
+		first  = newTestSse("first")
+		second = newTestSse("second")
+		group  sync.WaitGroup
+	)
+	var conn, received = newTestConnection(second, "alice")
+	second.Add(conn)
+
+	for _, sse := range []*Sse{first, second} {
+		if err := sse.UseBroker(broker); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for worker := 0; worker < 4; worker++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for i := 0; i < 100; i++ {
+				if err := first.SendToUser("alice", "hello"); err != nil {
+					t.Error(err)
+				}
+				if err := first.SendTo(second.Id(conn.Fd()), "hello"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	group.Add(1)
+	go func() {
+		defer group.Done()
+		for i := 0; i < 20; i++ {
+			_ = first.UseBroker(broker)
+		}
+	}()
+	group.Wait()
+
+	first.Unsubscribe()
+	second.Unsubscribe()
+	second.CloseAll()
+	if got := received(); got == 0 {
+		t.Fatal("expected messages to be delivered through the broker")
+	}
+}
+
+// reentrantController 在 OnClose 中访问注册表
+type reentrantController struct {
+	sse    *Sse
+	closed chan uint64
+}
+
+func (controller *reentrantController) OnConnect(contracts.HttpRequest, uint64) error {
+	return nil
+}
+
+func (controller *reentrantController) OnClose(fd uint64) {
+	_ = controller.sse.Count()
+	_ = controller.sse.Send(fd, "bye")
+	_ = controller.sse.Close(fd)
+	controller.closed <- fd
+}
+
+func TestPollingCloseCallsOnCloseWithoutLock(t *testing.T) {
+	var (
+		sse        = newTestSse("node")
+		controller = &reentrantController{sse: sse, closed: make(chan uint64, 2)}
+		config     = PollingConfig{IdleTimeout: 10 * time.Millisecond}.withDefaults()
+	)
+	for _, expire := range []bool{false, true} {
+		var fd = sse.GetFd()
+		var conn = newPollingConnection(fd, "token", "127.0.0.1", nil, config)
+		conn.onClose = func() { controller.OnClose(fd) }
+		sse.Add(conn)
+		if expire {
+			conn.touch(func() { _ = sse.Close(fd) })
+		} else {
+			_ = sse.Close(fd)
+		}
+
+		select {
+		case closed := <-controller.closed:
+			if closed != fd {
+				t.Fatalf("expected OnClose for fd %d, got %d", fd, closed)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("OnClose deadlocked on the registry lock")
+		}
+	}
+}
+
+func TestCrossNodeDelivery(t *testing.T) {
+	var (
+		broker = NewMemoryBroker()
+		first  = newTestSse("first")
+		second = newTestSse("second")
+		conn   = newPollingConnection(second.GetFd(), "token", "127.0.0.1", nil, DefaultPollingConfig())
+	)
+	if err := first.SendTo(second.Id(conn.Fd()), "lost"); err != ConnectionDontExistsErr {
+		t.Fatalf("expected ConnectionDontExistsErr without a broker, got %v", err)
+	}
+	for _, sse := range []*Sse{first, second} {
+		if err := sse.UseBroker(broker); err != nil {
+			t.Fatal(err)
+		}
+	}
+	second.Add(conn)
+	if err := second.Join(conn.Fd(), "room"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := first.SendTo(second.Id(conn.Fd()), "direct"); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Broadcast("room", "news"); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Broadcast("other", "ignored"); err != nil {
+		t.Fatal(err)
+	}
+
+	var result, _ = conn.take(0)
+	if fmt.Sprint(result.Messages) != "[direct news]" {
+		t.Fatalf("unexpected messages %v", result.Messages)
+	}
+}
+
+func TestParseId(t *testing.T) {
+	var node, fd, err = ParseId("host:10.0.0.1-42:7")
+	if err != nil || node != "host:10.0.0.1-42" || fd != 7 {
+		t.Fatalf("unexpected result %s %d %v", node, fd, err)
+	}
+	for _, id := range []string{"7", ":7", "node:", "node:abc"} {
+		if _, _, err = ParseId(id); err != ConnectionIdErr {
+			t.Fatalf("expected ConnectionIdErr for %q, got %v", id, err)
+		}
+	}
+}
+
+func TestUserAddressing(t *testing.T) {
+	var (
+		sse       = newTestSse("node")
+		config    = DefaultPollingConfig()
+		firstTab  = newPollingConnection(sse.GetFd(), "token", "127.0.0.1", nil, config)
+		secondTab = newPollingConnection(sse.GetFd(), "token", "127.0.0.1", nil, config)
+		other     = newPollingConnection(sse.GetFd(), "token", "127.0.0.1", nil, config)
+	)
+	firstTab.Identify("alice")
+	secondTab.Identify("alice")
+	other.Identify("bob")
+	for _, conn := range []*PollingConnection{firstTab, secondTab, other} {
+		sse.Add(conn)
+	}
+
+	if err := sse.SendToUser("alice", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	for _, conn := range []*PollingConnection{firstTab, secondTab} {
+		if result, _ := conn.take(0); fmt.Sprint(result.Messages) != "[hi]" {
+			t.Fatalf("every tab must receive the message, got %v", result.Messages)
+		}
+	}
+	if result, _ := other.take(0); len(result.Messages) != 0 {
+		t.Fatalf("unexpected messages for another user %v", result.Messages)
+	}
+
+	if err := sse.CloseUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sse.ConnectionsOf("alice")) != 0 || sse.Count() != 1 {
+		t.Fatal("expected CloseUser to close every connection of the user")
+	}
+	if err := sse.SendToUser("alice", "hi"); err != ConnectionDontExistsErr {
+		t.Fatalf("expected ConnectionDontExistsErr, got %v", err)
+	}
+}
+
+func TestIdentifyAfterAdd(t *testing.T) {
+	var (
+		sse  = newTestSse("node")
+		conn = newPollingConnection(sse.GetFd(), "token", "127.0.0.1", nil, DefaultPollingConfig())
+	)
+	sse.Add(conn)
+	conn.Identify("alice")
+	if len(sse.ConnectionsOf("alice")) != 1 {
+		t.Fatal("connection identified after Add is missing from the index")
+	}
+
+	conn.Identify("bob")
+	if len(sse.ConnectionsOf("alice")) != 0 || len(sse.ConnectionsOf("bob")) != 1 {
+		t.Fatal("expected the connection to move to the new key")
+	}
+
+	_ = sse.Close(conn.Fd())
+	conn.Identify("carol")
+	if len(sse.users) != 0 || len(sse.keys) != 0 {
+		t.Fatalf("index leaked after Close: %v %v", sse.users, sse.keys)
+	}
+}
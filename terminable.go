@@ -0,0 +1,148 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/exceptions"
+	"github.com/goal-web/supports/logs"
+	"github.com/labstack/echo/v4"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// TerminableMiddleware 带有结束阶段的中间件，Terminate 在响应发送并触发 RequestAfter 之后调用
+// 适合持久化 session、记录审计日志等不影响响应的工作，即使处理器 panic 也会调用，response 中可以获取最终的状态码
+type TerminableMiddleware interface {
+	Handle(request contracts.HttpRequest, next contracts.Pipe) interface{}
+	Terminate(request contracts.HttpRequest, response *echo.Response)
+}
+
+// terminatorsOf 获取中间件链中的 TerminableMiddleware
+func terminatorsOf(middlewares []contracts.MagicalFunc) []TerminableMiddleware {
+	var terminators []TerminableMiddleware
+	for _, middleware := range middlewares {
+		if named, isNamed := middleware.(*namedMiddleware); isNamed && named.terminable != nil {
+			terminators = append(terminators, named.terminable)
+		}
+	}
+	return terminators
+}
+
+// terminate 先结束响应，客户端收到完整的响应后再依次调用中间件的 Terminate，单个中间件 panic 不影响其他中间件
+func (this *Router) terminate(terminators []TerminableMiddleware, request contracts.HttpRequest, response *echo.Response) {
+	if len(terminators) == 0 {
+		return
+	}
+	if writer, isBuffered := response.Writer.(*bufferedWriter); isBuffered {
+		writer.finish()
+		response.Writer = writer.ResponseWriter
+	}
+	for _, terminator := range terminators {
+		func() {
+			defer func() {
+				if panicValue := recover(); panicValue != nil {
+					logs.WithException(exceptions.ResolveException(panicValue)).Error("http.terminate: middleware terminate failed")
+				}
+			}()
+			terminator.Terminate(request, response)
+		}()
+	}
+}
+
+// bufferLimit bufferedWriter 最多缓存的响应体大小，超过后改为直接写出
+const bufferLimit = 64 << 10
+
+// bufferedWriter 带有 TerminableMiddleware 的路由使用的 ResponseWriter，缓存较小的响应直到处理完成
+// 结束时带上 Content-Length 一次写出，客户端不需要等待 Terminate 执行完毕，处理器主动 Flush 时改为直接写出
+// 已经设置了 Content-Length 的响应（例如 File、ServeContent）直接写出，客户端同样在 Terminate 之前收到完整的响应；
+// 没有 Content-Length 且超过 bufferLimit 的响应也改为直接写出，避免把大文件读进内存，这时客户端要等 Terminate 结束才能收到响应结尾
+type bufferedWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	streaming bool
+}
+
+func newBufferedWriter(writer http.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: writer}
+}
+
+func (writer *bufferedWriter) WriteHeader(status int) {
+	if writer.streaming {
+		writer.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if writer.status == 0 {
+		writer.status = status
+	}
+}
+
+func (writer *bufferedWriter) Write(data []byte) (int, error) {
+	if writer.status == 0 && !writer.streaming {
+		writer.status = http.StatusOK
+	}
+	if !writer.streaming && (writer.ResponseWriter.Header().Get(echo.HeaderContentLength) != "" || writer.body.Len()+len(data) > bufferLimit) {
+		writer.stream()
+	}
+	if writer.streaming {
+		return writer.ResponseWriter.Write(data)
+	}
+	return writer.body.Write(data)
+}
+
+// Flush 处理器需要流式响应，写出已缓存的内容后不再缓存
+func (writer *bufferedWriter) Flush() {
+	writer.stream()
+	if flusher, isFlusher := writer.ResponseWriter.(http.Flusher); isFlusher {
+		flusher.Flush()
+	}
+}
+
+func (writer *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	writer.stream()
+	if hijacker, isHijacker := writer.ResponseWriter.(http.Hijacker); isHijacker {
+		return hijacker.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+func (writer *bufferedWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+func (writer *bufferedWriter) stream() {
+	if writer.streaming {
+		return
+	}
+	writer.streaming = true
+	if writer.status != 0 {
+		writer.ResponseWriter.WriteHeader(writer.status)
+	}
+	if writer.body.Len() > 0 {
+		_, _ = writer.ResponseWriter.Write(writer.body.Bytes())
+		writer.body.Reset()
+	}
+}
+
+// finish 带上 Content-Length 写出缓存的响应并发送给客户端，没有写入任何内容时保持原样
+func (writer *bufferedWriter) finish() {
+	if writer.streaming {
+		writer.Flush() // 把 net/http 缓冲区中剩余的内容发送出去
+		return
+	}
+	if writer.status == 0 {
+		return
+	}
+	var header = writer.ResponseWriter.Header()
+	if header.Get(echo.HeaderContentLength) == "" && header.Get("Transfer-Encoding") == "" && bodyAllowed(writer.status) {
+		header.Set(echo.HeaderContentLength, strconv.Itoa(writer.body.Len()))
+	}
+	writer.Flush()
+}
+
+// bodyAllowed 判断状态码是否允许响应体，1xx、204 和 304 不能带 Content-Length
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
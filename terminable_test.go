@@ -0,0 +1,147 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"github.com/goal-web/contracts"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingTerminator 的 Terminate 阻塞到 release 关闭
+type blockingTerminator struct {
+	release    chan struct{}
+	terminated chan int
+}
+
+func (terminator blockingTerminator) Handle(request contracts.HttpRequest, next contracts.Pipe) interface{} {
+	return next(request)
+}
+
+func (terminator blockingTerminator) Terminate(_ contracts.HttpRequest, response *echo.Response) {
+	<-terminator.release
+	terminator.terminated <- response.Status
+}
+
+func TestTerminateAfterResponse(t *testing.T) {
+	var (
+		router     = newTestRouter()
+		terminator = blockingTerminator{release: make(chan struct{}), terminated: make(chan int, 1)}
+		server     = httptest.NewServer(router)
+	)
+	defer server.Close()
+
+	var (
+		once    sync.Once
+		release = func() { once.Do(func() { close(terminator.release) }) }
+	)
+	defer release() // 失败时也要放行 Terminate，否则 server.Close 会一直等待
+	router.Get("/", func() string { return "done" }, terminator)
+
+	var received = make(chan string, 1)
+	go func() {
+		var response, err = server.Client().Get(server.URL)
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		var body, _ = io.ReadAll(response.Body)
+		received <- string(body)
+	}()
+
+	select {
+	case body := <-received:
+		if body != "done" {
+			t.Fatalf("unexpected body %q", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("client is still waiting for Terminate")
+	}
+
+	release()
+	if status := <-terminator.terminated; status != 200 {
+		t.Fatalf("unexpected status %d in Terminate", status)
+	}
+}
+
+// recordingTerminator 记录 Terminate 收到的状态码
+type recordingTerminator struct {
+	terminated chan int
+}
+
+func (terminator recordingTerminator) Handle(request contracts.HttpRequest, next contracts.Pipe) interface{} {
+	return next(request)
+}
+
+func (terminator recordingTerminator) Terminate(_ contracts.HttpRequest, response *echo.Response) {
+	terminator.terminated <- response.Status
+}
+
+func TestTerminateAfterPanic(t *testing.T) {
+	var (
+		router     = newTestRouter()
+		terminator = recordingTerminator{terminated: make(chan int, 1)}
+	)
+	router.Get("/", func() string { panic(errors.New("boom")) }, terminator)
+
+	var response = serve(router, http.MethodGet, "/")
+	select {
+	case status := <-terminator.terminated:
+		if status != response.Code || status != http.StatusInternalServerError {
+			t.Fatalf("expected Terminate to see the 500 response, got %d and response %d", status, response.Code)
+		}
+	default:
+		t.Fatal("Terminate was not called after the handler panicked")
+	}
+}
+
+func TestBufferedWriterStreamsLargeBodies(t *testing.T) {
+	var (
+		recorder = httptest.NewRecorder()
+		writer   = newBufferedWriter(recorder)
+		chunk    = bytes.Repeat([]byte("a"), bufferLimit/2)
+	)
+	for i := 0; i < 3; i++ {
+		_, _ = writer.Write(chunk)
+	}
+	if writer.body.Len() > bufferLimit || recorder.Body.Len() == 0 {
+		t.Fatalf("expected bodies over %d bytes to be streamed, %d bytes still buffered", bufferLimit, writer.body.Len())
+	}
+	writer.finish()
+	if recorder.Body.Len() != 3*len(chunk) || recorder.Header().Get(echo.HeaderContentLength) != "" {
+		t.Fatalf("unexpected streamed response of %d bytes", recorder.Body.Len())
+	}
+}
+
+func TestBufferedWriterStreamsKnownLength(t *testing.T) {
+	var (
+		router     = newTestRouter()
+		terminator = recordingTerminator{terminated: make(chan int, 1)}
+		content    = bytes.Repeat([]byte("b"), 1024)
+	)
+	router.Get("/file", func(request *Request) error {
+		var response = request.Response()
+		response.Header().Set(echo.HeaderContentLength, strconv.Itoa(len(content)))
+		response.WriteHeader(http.StatusOK)
+		if _, err := response.Write(content[:1]); err != nil {
+			return err
+		}
+		if buffered, isBuffered := response.Writer.(*bufferedWriter); !isBuffered || !buffered.streaming {
+			t.Error("responses with Content-Length must be written without buffering")
+		}
+		_, err := response.Write(content[1:])
+		return err
+	}, terminator)
+
+	var response = serve(router, http.MethodGet, "/file")
+	if !bytes.Equal(response.Body.Bytes(), content) || <-terminator.terminated != http.StatusOK {
+		t.Fatalf("unexpected response of %d bytes", response.Body.Len())
+	}
+}
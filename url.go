@@ -0,0 +1,81 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/goal-web/contracts"
+	"net/url"
+	"strings"
+)
+
+var (
+	RouteNotFoundError = errors.New("route not found")
+	MissingParamError  = errors.New("missing route param") // 路径或者域名中的参数没有传值
+)
+
+// Url 根据路由名称生成 url，params 会替换路径和域名中的同名参数，多余的参数作为查询字符串
+// 带有域名的路由生成不带协议的 url，例如 //acme.example.com/users/1，路由装配之后才可以使用
+// 路径和域名中的参数缺少时返回 MissingParamError，通配符 * 可以省略
+func (this *Router) Url(name string, params ...contracts.Fields) (string, error) {
+	var mounted, exists = this.named[name]
+	if !exists {
+		return "", RouteNotFoundError
+	}
+
+	var (
+		fields   = contracts.Fields{}
+		used     = make(map[string]bool)
+		segments = strings.Split(mounted.route.Path(), "/")
+		query    = url.Values{}
+		host     string
+	)
+	for _, param := range params {
+		for key, value := range param {
+			fields[key] = value
+		}
+	}
+
+	for index, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			var param = segment[1:]
+			if colon := strings.Index(param, ":"); colon > 0 {
+				param = param[:colon]
+			}
+			var value, exists = fields[param]
+			if !exists || value == nil {
+				return "", MissingParamError
+			}
+			used[param] = true
+			segments[index] = url.PathEscape(fmt.Sprint(value))
+		case segment == "*":
+			used["*"] = true
+			if value, exists := fields["*"]; exists && value != nil {
+				segments[index] = fmt.Sprint(value)
+			} else {
+				segments[index] = ""
+			}
+		}
+	}
+
+	if mounted.domain != nil {
+		var domain, err = mounted.domain.build(fields, used)
+		if err != nil {
+			return "", err
+		}
+		host = "//" + domain
+	}
+
+	for key, value := range fields {
+		if !used[key] {
+			query.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	var result = host + strings.Join(segments, "/")
+	if len(query) > 0 {
+		result += "?" + query.Encode()
+	}
+
+	return result, nil
+}
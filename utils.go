@@ -1,15 +1,27 @@
 package http
 
 import (
-	"github.com/goal-web/container"
 	"github.com/goal-web/contracts"
 )
 
+// parseRouteArgs 把路由的可变参数拆分成中间件和路由选项
+func parseRouteArgs(args ...interface{}) (middlewares []contracts.MagicalFunc, options []RouteOption) {
+	var rawMiddlewares = make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if option, isOption := arg.(RouteOption); isOption {
+			options = append(options, option)
+		} else {
+			rawMiddlewares = append(rawMiddlewares, arg)
+		}
+	}
+	return convertToMiddlewares(rawMiddlewares...), options
+}
+
 func convertToMiddlewares(middlewares ...interface{}) (results []contracts.MagicalFunc) {
 	for _, middleware := range middlewares {
 		magicalFunc, isMiddleware := middleware.(contracts.MagicalFunc)
 		if !isMiddleware {
-			magicalFunc = container.NewMagicalFunc(middleware)
+			magicalFunc = newMagicalMiddleware(middleware)
 		}
 		if magicalFunc.NumOut() != 1 {
 			panic(MiddlewareError)
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const versionKey = "goal.api.version"
+
+var vendorMediaType = regexp.MustCompile(`application/vnd\.([^.;+]+)\.([^.;+]+)`)
+
+// VersioningConfig api 版本协商配置，没有版本前缀的请求按请求头、Accept、默认版本的顺序选择版本
+type VersioningConfig struct {
+	// Default 请求没有指定版本时使用的版本，为空时使用第一个注册的版本
+	Default string
+
+	// Header 指定版本的请求头，默认为 X-Api-Version
+	Header string
+
+	// Vendor Accept 中的厂商名，例如 app 对应 application/vnd.app.v2+json，为空时不限制厂商
+	Vendor string
+}
+
+// apiVersion 版本路由组的版本信息
+type apiVersion struct {
+	name   string
+	prefix string
+}
+
+// deprecation 路由的弃用信息，响应时输出 Deprecation、Sunset 和 Link 头
+type deprecation struct {
+	sunset time.Time
+	link   string
+}
+
+// Deprecated 标记路由或者路由组已弃用，sunset 为零值时不输出 Sunset 头，link 为说明文档的地址
+func Deprecated(sunset time.Time, link ...string) RouteOption {
+	var info = &deprecation{sunset: sunset}
+	if len(link) > 0 {
+		info.link = link[0]
+	}
+	return func(attributes *attributes) {
+		attributes.deprecation = info
+	}
+}
+
+// Versioning 设置 api 版本协商方式
+func (this *Router) Versioning(config VersioningConfig) {
+	if config.Header == "" {
+		config.Header = "X-Api-Version"
+	}
+	this.versioning = config
+}
+
+// Version 添加版本路由组，组内的路由既可以通过 prefix/version/path 访问，也可以通过 prefix/path 加版本协商访问
+// 例如 router.Version("v2", "/api").Get("/users", handler) 可以匹配 /api/v2/users 和带有 X-Api-Version: v2 的 /api/users
+func (this *Router) Version(version, prefix string, middlewares ...interface{}) contracts.RouteGroup {
+	var groupInstance = NewGroup(prefix, middlewares...).(*group)
+	groupInstance.version = &apiVersion{name: version, prefix: prefix}
+
+	this.groups = append(this.groups, groupInstance)
+	if !contains(this.versions, version) {
+		this.versions = append(this.versions, version)
+	}
+
+	return groupInstance
+}
+
+// RouteVersions 获取每个路由支持的版本，键为 "GET /api/users" 这样的方法加路径，路由装配之后才可以使用
+func (this *Router) RouteVersions() map[string][]string {
+	var result = make(map[string][]string, len(this.routeVersions))
+	for key, versions := range this.routeVersions {
+		result[key] = append([]string{}, versions...)
+	}
+	return result
+}
+
+// RequestVersion 获取处理当前请求的路由的 api 版本，不是版本路由时返回空字符串
+func RequestVersion(request contracts.HttpRequest) string {
+	if version, ok := request.Get(versionKey).(string); ok {
+		return version
+	}
+	return ""
+}
+
+// negotiateVersion 从请求头、Accept 中解析请求的版本，都没有时使用默认版本
+func (this *Router) negotiateVersion(context echo.Context) string {
+	if version := strings.TrimSpace(context.Request().Header.Get(this.versioning.Header)); version != "" {
+		return this.normalizeVersion(version)
+	}
+
+	for _, match := range vendorMediaType.FindAllStringSubmatch(context.Request().Header.Get(echo.HeaderAccept), -1) {
+		if this.versioning.Vendor == "" || strings.EqualFold(match[1], this.versioning.Vendor) {
+			return this.normalizeVersion(match[2])
+		}
+	}
+
+	if this.versioning.Default != "" {
+		return this.versioning.Default
+	}
+	if len(this.versions) > 0 {
+		return this.versions[0]
+	}
+	return ""
+}
+
+// normalizeVersion 兼容不带 v 前缀的版本号，例如 2 对应 v2
+func (this *Router) normalizeVersion(version string) string {
+	if !contains(this.versions, version) && contains(this.versions, "v"+version) {
+		return "v" + version
+	}
+	return version
+}
+
+// writeDeprecation 输出弃用相关的响应头
+func writeDeprecation(header http.Header, info *deprecation) {
+	header.Set("Deprecation", "true")
+	if !info.sunset.IsZero() {
+		header.Set("Sunset", info.sunset.UTC().Format(http.TimeFormat))
+	}
+	if info.link != "" {
+		header.Add("Link", "<"+info.link+">; rel=\"deprecation\"")
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"github.com/goal-web/contracts"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVersionedRoutes(t *testing.T) {
+	var (
+		router = newTestRouter()
+		sunset = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	router.Version("v1", "/api", Deprecated(sunset, "https://example.com/v2")).Get("/users/:id", func(request contracts.HttpRequest) string {
+		return "v1 " + RequestVersion(request)
+	})
+	router.Version("v2", "/api").Get("/users/:id", func(request contracts.HttpRequest) string {
+		return "v2 " + RequestVersion(request)
+	})
+	router.Versioning(VersioningConfig{Default: "v2", Vendor: "app"})
+
+	for _, item := range []struct {
+		path, header, value, expected string
+	}{
+		{"/api/v1/users/1", "", "", "v1 v1"},
+		{"/api/v2/users/1", "", "", "v2 v2"},
+		{"/api/users/1", "", "", "v2 v2"},
+		{"/api/users/1", "X-Api-Version", "1", "v1 v1"},
+		{"/api/users/1", "Accept", "application/vnd.app.v1+json", "v1 v1"},
+		{"/api/users/1", "Accept", "application/vnd.other.v1+json", "v2 v2"},
+	} {
+		var (
+			recorder = httptest.NewRecorder()
+			request  = httptest.NewRequest(http.MethodGet, item.path, nil)
+		)
+		if item.header != "" {
+			request.Header.Set(item.header, item.value)
+		}
+		router.ServeHTTP(recorder, request)
+		if recorder.Body.String() != item.expected {
+			t.Errorf("%s %s=%s: expected %q, got %q", item.path, item.header, item.value, item.expected, recorder.Body.String())
+		}
+	}
+
+	var request = httptest.NewRequest(http.MethodGet, "/api/users/1", nil)
+	request.Header.Set("X-Api-Version", "v9")
+	if response := serveRecorder(router, request); response.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown version, got %d", response.Code)
+	}
+
+	var deprecated = serve(router, http.MethodGet, "/api/v1/users/1").Header()
+	if deprecated.Get("Deprecation") != "true" || deprecated.Get("Sunset") != sunset.Format(http.TimeFormat) ||
+		deprecated.Get("Link") != `<https://example.com/v2>; rel="deprecation"` {
+		t.Errorf("unexpected deprecation headers %v", deprecated)
+	}
+	if current := serve(router, http.MethodGet, "/api/v2/users/1").Header(); current.Get("Deprecation") != "" {
+		t.Error("current version must not be deprecated")
+	}
+	if negotiated := serve(router, http.MethodGet, "/api/users/1").Header(); negotiated.Get("Vary") == "" {
+		t.Error("negotiated responses must vary on the version headers")
+	}
+
+	if versions := router.RouteVersions()["GET /api/users/:id"]; !reflect.DeepEqual(versions, []string{"v1", "v2"}) {
+		t.Errorf("unexpected route versions %v", router.RouteVersions())
+	}
+}
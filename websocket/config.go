@@ -0,0 +1,67 @@
+package websocket
+
+import (
+	"compress/flate"
+	"net/http"
+	"time"
+)
+
+type Config struct {
+	// MaxMessageSize 单条消息的最大字节数，超出时关闭连接，0 表示使用默认值，负数表示不限制
+	MaxMessageSize int64
+
+	// PingInterval 发送 ping 的间隔，0 表示使用默认值，负数表示不发送 ping
+	PingInterval time.Duration
+
+	// PongWait 等待 pong 的超时时间，应该大于 PingInterval，0 表示 PingInterval 的 1.2 倍
+	PongWait time.Duration
+
+	// WriteWait 写消息的超时时间
+	WriteWait time.Duration
+
+	// ReadBufferSize、WriteBufferSize 读写缓冲区大小，0 表示使用默认值
+	ReadBufferSize  int
+	WriteBufferSize int
+
+	// Compression 是否启用 permessage-deflate 压缩
+	Compression bool
+
+	// CompressionLevel 压缩级别，参考 compress/flate，nil 表示使用默认压缩级别，flate.NoCompression 也可以设置
+	CompressionLevel *int
+
+	// CheckOrigin 校验请求来源，为空时只允许同源请求
+	CheckOrigin func(r *http.Request) bool
+}
+
+func DefaultConfig() Config {
+	var level = flate.DefaultCompression
+	return Config{
+		MaxMessageSize:   64 * 1024,
+		PingInterval:     50 * time.Second,
+		PongWait:         60 * time.Second,
+		WriteWait:        10 * time.Second,
+		Compression:      false,
+		CompressionLevel: &level,
+	}
+}
+
+// withDefaults 逐个字段使用默认值填充未设置的配置，只设置了部分字段的配置也可以直接使用
+func (config Config) withDefaults() Config {
+	var defaults = DefaultConfig()
+	if config.MaxMessageSize == 0 {
+		config.MaxMessageSize = defaults.MaxMessageSize
+	}
+	if config.PingInterval == 0 {
+		config.PingInterval = defaults.PingInterval
+	}
+	if config.PongWait == 0 {
+		config.PongWait = config.PingInterval * 6 / 5
+	}
+	if config.WriteWait == 0 {
+		config.WriteWait = defaults.WriteWait
+	}
+	if config.CompressionLevel == nil {
+		config.CompressionLevel = defaults.CompressionLevel
+	}
+	return config
+}
@@ -0,0 +1,114 @@
+package websocket
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/gorilla/websocket"
+	"sync"
+	"time"
+)
+
+const (
+	TextMessage   = websocket.TextMessage
+	BinaryMessage = websocket.BinaryMessage
+)
+
+type Connection struct {
+	fd         uint64
+	ws         *websocket.Conn
+	serializer contracts.Serializer
+	writeWait  time.Duration
+
+	writeMutex  sync.Mutex
+	closeOnce   sync.Once
+	done        chan struct{}
+	connectedAt time.Time
+}
+
+// NewConnection 创建连接，writeWait 不大于 0 时使用默认的写超时
+func NewConnection(ws *websocket.Conn, fd uint64, serializer contracts.Serializer, writeWait time.Duration) *Connection {
+	if writeWait <= 0 {
+		writeWait = DefaultConfig().WriteWait
+	}
+	return &Connection{
+		fd:          fd,
+		ws:          ws,
+		serializer:  serializer,
+		writeWait:   writeWait,
+		done:        make(chan struct{}),
+		connectedAt: time.Now(),
+	}
+}
+
+func (conn *Connection) Fd() uint64 {
+	return conn.fd
+}
+
+// ConnectedAt 获取连接建立的时间
+func (conn *Connection) ConnectedAt() time.Time {
+	return conn.connectedAt
+}
+
+// RemoteAddr 获取客户端地址
+func (conn *Connection) RemoteAddr() string {
+	return conn.ws.RemoteAddr().String()
+}
+
+// Done 连接关闭后会关闭该 channel
+func (conn *Connection) Done() <-chan struct{} {
+	return conn.done
+}
+
+// Send 发送文本消息，非字符串的消息会先序列化
+func (conn *Connection) Send(message interface{}) error {
+	switch msg := message.(type) {
+	case []byte:
+		return conn.write(TextMessage, msg)
+	case string:
+		return conn.write(TextMessage, []byte(msg))
+	default:
+		return conn.write(TextMessage, []byte(conn.serializer.Serialize(message)))
+	}
+}
+
+func (conn *Connection) SendBytes(bytes []byte) error {
+	return conn.write(TextMessage, bytes)
+}
+
+func (conn *Connection) SendBinary(bytes []byte) error {
+	return conn.write(BinaryMessage, bytes)
+}
+
+func (conn *Connection) Close() error {
+	var err error
+	conn.closeOnce.Do(func() {
+		close(conn.done)
+		_ = conn.ws.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(conn.writeWait),
+		)
+		err = conn.ws.Close()
+	})
+	return err
+}
+
+// ping 发送心跳，WriteControl 可以和其他写操作并发调用
+func (conn *Connection) ping() error {
+	return conn.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(conn.writeWait))
+}
+
+func (conn *Connection) write(messageType int, data []byte) error {
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+
+	select {
+	case <-conn.done:
+		return ConnectionClosedErr
+	default:
+	}
+
+	if err := conn.ws.SetWriteDeadline(time.Now().Add(conn.writeWait)); err != nil {
+		return err
+	}
+	return conn.ws.WriteMessage(messageType, data)
+}
@@ -0,0 +1,53 @@
+package websocket
+
+import (
+	"encoding/json"
+	"github.com/goal-web/contracts"
+)
+
+type Frame struct {
+	conn        *Connection
+	messageType int
+	raw         []byte
+}
+
+func NewFrame(conn *Connection, messageType int, raw []byte) contracts.WebSocketFrame {
+	return &Frame{
+		conn:        conn,
+		messageType: messageType,
+		raw:         raw,
+	}
+}
+
+// IsBinary 判断是否为二进制消息
+func (frame *Frame) IsBinary() bool {
+	return frame.messageType == BinaryMessage
+}
+
+func (frame *Frame) Connection() contracts.WebSocketConnection {
+	return frame.conn
+}
+
+func (frame *Frame) Raw() []byte {
+	return frame.raw
+}
+
+func (frame *Frame) RawString() string {
+	return string(frame.raw)
+}
+
+func (frame *Frame) Parse(v interface{}) error {
+	return json.Unmarshal(frame.raw, v)
+}
+
+func (frame *Frame) Send(message interface{}) error {
+	return frame.conn.Send(message)
+}
+
+func (frame *Frame) SendBytes(bytes []byte) error {
+	return frame.conn.SendBytes(bytes)
+}
+
+func (frame *Frame) SendBinary(bytes []byte) error {
+	return frame.conn.SendBinary(bytes)
+}
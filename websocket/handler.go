@@ -0,0 +1,90 @@
+package websocket
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/http"
+	"github.com/goal-web/supports/logs"
+	"github.com/gorilla/websocket"
+	"time"
+)
+
+// New 创建 websocket 路由处理器，可以像普通路由一样挂载并使用中间件
+func New(controller contracts.WebSocketController, config ...Config) interface{} {
+	var conf = DefaultConfig()
+	if len(config) > 0 {
+		conf = config[0].withDefaults()
+	}
+	var upgrader = websocket.Upgrader{
+		ReadBufferSize:    conf.ReadBufferSize,
+		WriteBufferSize:   conf.WriteBufferSize,
+		EnableCompression: conf.Compression,
+		CheckOrigin:       conf.CheckOrigin,
+	}
+
+	return func(request *http.Request, serializer contracts.Serializer, socket contracts.WebSocket) error {
+		var fd = socket.GetFd()
+		if err := controller.OnConnect(request, fd); err != nil {
+			logs.WithError(err).WithFields(request.Fields()).WithField("fd", fd).Debug("websocket.New: OnConnect failed")
+			return err
+		}
+
+		var ws, err = upgrader.Upgrade(request.Response(), request.Request(), nil)
+		if err != nil { // Upgrade 已经响应了错误，连接没有加入注册表，不调用 OnClose
+			logs.WithError(err).WithField("fd", fd).Debug("websocket.New: upgrade failed")
+			return nil
+		}
+
+		if conf.Compression {
+			ws.EnableWriteCompression(true)
+			_ = ws.SetCompressionLevel(*conf.CompressionLevel)
+		}
+		if conf.MaxMessageSize > 0 {
+			ws.SetReadLimit(conf.MaxMessageSize)
+		}
+
+		var conn = NewConnection(ws, fd, serializer, conf.WriteWait)
+		socket.Add(conn)
+
+		defer func() {
+			_ = socket.Close(fd)
+			controller.OnClose(fd)
+		}()
+
+		if conf.PingInterval > 0 {
+			_ = ws.SetReadDeadline(time.Now().Add(conf.PongWait))
+			ws.SetPongHandler(func(string) error {
+				return ws.SetReadDeadline(time.Now().Add(conf.PongWait))
+			})
+			go keepAlive(conn, conf.PingInterval)
+		}
+
+		for {
+			var messageType, message, readErr = ws.ReadMessage()
+			if readErr != nil {
+				if websocket.IsUnexpectedCloseError(readErr, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					logs.WithError(readErr).WithField("fd", fd).Debug("websocket.New: read failed")
+				}
+				return nil
+			}
+			controller.OnMessage(NewFrame(conn, messageType, message))
+		}
+	}
+}
+
+// keepAlive 定时发送 ping，直到连接关闭
+func keepAlive(conn *Connection, interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := conn.ping(); err != nil {
+				_ = conn.Close()
+				return
+			}
+		case <-conn.Done():
+			return
+		}
+	}
+}
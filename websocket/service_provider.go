@@ -0,0 +1,40 @@
+package websocket
+
+import (
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/http"
+	"sync"
+)
+
+type ServiceProvider struct {
+}
+
+// Register 注册 websocket 实例，http 服务关闭时关闭所有连接
+func (s ServiceProvider) Register(application contracts.Application) {
+	application.Singleton("websocket", func(events contracts.EventDispatcher) contracts.WebSocket {
+		var socket = &WebSocket{
+			fdMutex:     sync.Mutex{},
+			connMutex:   sync.RWMutex{},
+			connections: map[uint64]contracts.WebSocketConnection{},
+			count:       0,
+		}
+		events.Register((&http.ServeClosed{}).Event(), closeListener{socket: socket})
+		return socket
+	})
+}
+
+func (s ServiceProvider) Start() error {
+	return nil
+}
+
+func (s ServiceProvider) Stop() {
+}
+
+// closeListener http 服务关闭后关闭所有连接
+type closeListener struct {
+	socket *WebSocket
+}
+
+func (listener closeListener) Handle(contracts.Event) {
+	listener.socket.CloseAll()
+}
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"errors"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/supports/logs"
+	"sync"
+)
+
+var (
+	ConnectionDontExistsErr = errors.New("connection does not exist")
+	ConnectionClosedErr     = errors.New("connection is closed")
+)
+
+type WebSocket struct {
+	fdMutex     sync.Mutex
+	connMutex   sync.RWMutex
+	connections map[uint64]contracts.WebSocketConnection
+	count       uint64
+}
+
+func (socket *WebSocket) Add(connect contracts.WebSocketConnection) {
+	socket.connMutex.Lock()
+	defer socket.connMutex.Unlock()
+	socket.connections[connect.Fd()] = connect
+}
+
+func (socket *WebSocket) GetFd() uint64 {
+	socket.fdMutex.Lock()
+	defer socket.fdMutex.Unlock()
+	socket.count++
+	var fd = socket.count
+	return fd
+}
+
+func (socket *WebSocket) Close(fd uint64) error {
+	socket.connMutex.Lock()
+	var conn, exists = socket.connections[fd]
+	delete(socket.connections, fd)
+	socket.connMutex.Unlock()
+
+	if exists {
+		return conn.Close()
+	}
+
+	return ConnectionDontExistsErr
+}
+
+func (socket *WebSocket) Send(fd uint64, message interface{}) error {
+	if conn := socket.Get(fd); conn != nil {
+		return conn.Send(message)
+	}
+
+	return ConnectionDontExistsErr
+}
+
+// Get 获取指定 fd 的连接，不存在时返回 nil
+func (socket *WebSocket) Get(fd uint64) contracts.WebSocketConnection {
+	socket.connMutex.RLock()
+	defer socket.connMutex.RUnlock()
+	return socket.connections[fd]
+}
+
+// Count 获取连接数
+func (socket *WebSocket) Count() int {
+	socket.connMutex.RLock()
+	defer socket.connMutex.RUnlock()
+	return len(socket.connections)
+}
+
+// Range 遍历连接的快照，callback 返回 false 时停止遍历
+func (socket *WebSocket) Range(callback func(conn contracts.WebSocketConnection) bool) {
+	for _, conn := range socket.snapshot() {
+		if !callback(conn) {
+			return
+		}
+	}
+}
+
+// Broadcast 发送消息给所有连接
+func (socket *WebSocket) Broadcast(message interface{}) {
+	for _, conn := range socket.snapshot() {
+		if err := conn.Send(message); err != nil {
+			logs.WithError(err).WithField("fd", conn.Fd()).Debug("websocket.Broadcast: send failed")
+		}
+	}
+}
+
+// CloseAll 关闭所有连接，通常在服务关闭时调用
+func (socket *WebSocket) CloseAll() {
+	for _, conn := range socket.snapshot() {
+		_ = socket.Close(conn.Fd())
+	}
+}
+
+func (socket *WebSocket) snapshot() []contracts.WebSocketConnection {
+	socket.connMutex.RLock()
+	defer socket.connMutex.RUnlock()
+	var connections = make([]contracts.WebSocketConnection, 0, len(socket.connections))
+	for _, conn := range socket.connections {
+		connections = append(connections, conn)
+	}
+	return connections
+}
@@ -0,0 +1,262 @@
+package websocket
+
+import (
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/goal-web/contracts"
+	"github.com/goal-web/http"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	stdhttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testSerializer struct{}
+
+func (testSerializer) Serialize(value interface{}) string {
+	var data, _ = json.Marshal(value)
+	return string(data)
+}
+
+func (testSerializer) Unserialize(data string, value interface{}) error {
+	return json.Unmarshal([]byte(data), value)
+}
+
+// testController 记录连接事件，reject 不为空时拒绝连接
+type testController struct {
+	reject    error
+	connected chan uint64
+	messages  chan string
+	closed    chan uint64
+}
+
+func newTestController(reject error) *testController {
+	return &testController{
+		reject:    reject,
+		connected: make(chan uint64, 10),
+		messages:  make(chan string, 10),
+		closed:    make(chan uint64, 10),
+	}
+}
+
+func (controller *testController) OnConnect(_ contracts.HttpRequest, fd uint64) error {
+	if controller.reject != nil {
+		return controller.reject
+	}
+	controller.connected <- fd
+	return nil
+}
+
+func (controller *testController) OnMessage(frame contracts.WebSocketFrame) {
+	controller.messages <- frame.RawString()
+}
+
+func (controller *testController) OnClose(fd uint64) {
+	controller.closed <- fd
+}
+
+// newTestServer 在测试服务器上挂载 websocket 处理器，返回注册表和 ws 地址
+func newTestServer(t *testing.T, controller contracts.WebSocketController, config ...Config) (*WebSocket, string) {
+	var (
+		socket  = &WebSocket{connections: map[uint64]contracts.WebSocketConnection{}}
+		handler = New(controller, config...).(func(*http.Request, contracts.Serializer, contracts.WebSocket) error)
+		server  = httptest.NewServer(stdhttp.HandlerFunc(func(w stdhttp.ResponseWriter, r *stdhttp.Request) {
+			var request = http.NewRequest(echo.New().NewContext(r, w)).(*http.Request)
+			if err := handler(request, testSerializer{}, socket); err != nil {
+				w.WriteHeader(stdhttp.StatusForbidden)
+			}
+		}))
+	)
+	t.Cleanup(func() {
+		socket.CloseAll()
+		server.Close()
+	})
+	return socket, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	var ws, _, err = websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+	return ws
+}
+
+func receive(t *testing.T, channel chan uint64, event string) uint64 {
+	select {
+	case fd := <-channel:
+		return fd
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s was not called", event)
+		return 0
+	}
+}
+
+func TestMessagesAndConcurrentSend(t *testing.T) {
+	var (
+		controller  = newTestController(nil)
+		socket, url = newTestServer(t, controller)
+		ws          = dial(t, url)
+		fd          = receive(t, controller.connected, "OnConnect")
+		group       sync.WaitGroup
+	)
+
+	if err := ws.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if message := <-controller.messages; message != "hello" {
+		t.Fatalf("unexpected message %q", message)
+	}
+
+	for worker := 0; worker < 8; worker++ {
+		group.Add(1)
+		go func(worker int) {
+			defer group.Done()
+			for i := 0; i < 50; i++ {
+				if err := socket.Send(fd, fmt.Sprintf("%d-%d", worker, i)); err != nil {
+					t.Error(err)
+				}
+			}
+		}(worker)
+	}
+
+	var received = make(map[string]bool)
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(received) < 400 {
+		var _, message, err = ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("received %d messages: %v", len(received), err)
+		}
+		received[string(message)] = true
+	}
+	group.Wait()
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	var (
+		controller  = newTestController(nil)
+		socket, url = newTestServer(t, controller, Config{MaxMessageSize: 8})
+		ws          = dial(t, url)
+		fd          = receive(t, controller.connected, "OnConnect")
+	)
+
+	if err := ws.WriteMessage(websocket.TextMessage, []byte("more than eight bytes")); err != nil {
+		t.Fatal(err)
+	}
+	if closed := receive(t, controller.closed, "OnClose"); closed != fd {
+		t.Fatalf("unexpected OnClose for fd %d", closed)
+	}
+	var _, _, err = ws.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("expected close 1009, got %v", err)
+	}
+	if socket.Count() != 0 {
+		t.Fatal("oversized message must remove the connection")
+	}
+}
+
+func TestPingKeepsConnectionAlive(t *testing.T) {
+	var (
+		controller  = newTestController(nil)
+		socket, url = newTestServer(t, controller, Config{PingInterval: 20 * time.Millisecond, PongWait: 60 * time.Millisecond})
+		ws          = dial(t, url)
+	)
+	receive(t, controller.connected, "OnConnect")
+
+	go func() { // 读取时会自动响应 ping
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-controller.closed:
+		t.Fatal("connection answering pings was closed")
+	case <-time.After(300 * time.Millisecond):
+	}
+	if socket.Count() != 1 {
+		t.Fatal("expected the connection to stay registered")
+	}
+}
+
+func TestMissingPongClosesConnection(t *testing.T) {
+	var (
+		controller  = newTestController(nil)
+		socket, url = newTestServer(t, controller, Config{PingInterval: 20 * time.Millisecond, PongWait: 60 * time.Millisecond})
+	)
+	dial(t, url) // 不读取就不会响应 ping
+	var fd = receive(t, controller.connected, "OnConnect")
+
+	if closed := receive(t, controller.closed, "OnClose"); closed != fd {
+		t.Fatalf("unexpected OnClose for fd %d", closed)
+	}
+	if socket.Count() != 0 {
+		t.Fatal("expected the connection to be removed after PongWait")
+	}
+}
+
+func TestOnConnectRejected(t *testing.T) {
+	var (
+		controller  = newTestController(errors.New("unauthorized"))
+		socket, url = newTestServer(t, controller)
+	)
+
+	var _, response, err = websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || response == nil || response.StatusCode != stdhttp.StatusForbidden {
+		t.Fatalf("expected the handshake to be rejected, got %v", err)
+	}
+	assertNotClosed(t, controller)
+	if socket.Count() != 0 {
+		t.Fatal("rejected connection must not be registered")
+	}
+}
+
+func TestUpgradeFailureSkipsOnClose(t *testing.T) {
+	var (
+		controller  = newTestController(nil)
+		socket, url = newTestServer(t, controller)
+	)
+
+	var response, err = stdhttp.Get("http" + strings.TrimPrefix(url, "ws"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != stdhttp.StatusBadRequest {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+	assertNotClosed(t, controller)
+	if socket.Count() != 0 {
+		t.Fatal("failed upgrade must not be registered")
+	}
+}
+
+func assertNotClosed(t *testing.T, controller *testController) {
+	select {
+	case fd := <-controller.closed:
+		t.Fatalf("OnClose was called for fd %d that was never added", fd)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	var config = Config{PingInterval: time.Second}.withDefaults()
+	if config.PongWait != 1200*time.Millisecond || config.WriteWait != DefaultConfig().WriteWait ||
+		config.MaxMessageSize != DefaultConfig().MaxMessageSize || *config.CompressionLevel != flate.DefaultCompression {
+		t.Fatalf("unexpected defaults %+v", config)
+	}
+
+	var level = flate.NoCompression
+	if config = (Config{CompressionLevel: &level}).withDefaults(); *config.CompressionLevel != flate.NoCompression {
+		t.Fatalf("NoCompression must be selectable, got %d", *config.CompressionLevel)
+	}
+}